@@ -31,6 +31,7 @@ import (
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strconv"
 	"strings"
 	"testing"
@@ -64,7 +65,9 @@ func testBindError(t *testing.T, r io.Reader, ctype string, expectedInternal err
 	u := new(user)
 	err := ctx.Bind(u)
 
-	assert.IsType(t, expectedInternal, err)
+	if assert.IsType(t, BindError{}, err) {
+		assert.IsType(t, expectedInternal, err.(BindError).Err)
+	}
 }
 
 type (
@@ -265,6 +268,28 @@ func TestBindMultipartForm(t *testing.T) {
 	testBindOkay(t, body, mw.FormDataContentType())
 }
 
+func TestBindMalformedForm(t *testing.T) {
+	s := New()
+	s.Route("/").POST(func(ctx *Context) error { return ctx.Bind(new(user)) })
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("id=1&name=%zz"))
+	req.Header.Set(HeaderContentType, MIMEApplicationForm)
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.NotEmpty(t, rec.Body.String())
+
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("id=1&name=%zz"))
+	req.Header.Set(HeaderContentType, MIMEApplicationForm)
+	ctx := s.AcquireContext(req, httptest.NewRecorder())
+	err := ctx.Bind(new(user))
+	if assert.IsType(t, BindError{}, err) {
+		assert.Equal(t, "form", err.(BindError).Source)
+		assert.IsType(t, url.EscapeError(""), err.(BindError).Err)
+	}
+	s.ReleaseContext(ctx)
+}
+
 func TestBindUnsupportedMediaType(t *testing.T) {
 	testBindError(t, strings.NewReader(invalidContent), MIMEApplicationJSON,
 		&json.SyntaxError{})
@@ -280,5 +305,253 @@ func TestBindUnmarshalTypeError(t *testing.T) {
 	u := new(user)
 
 	err := ctx.Bind(u)
-	assert.Equal(t, "json: cannot unmarshal string into Go struct field user.id of type int", err.Error())
+	if assert.IsType(t, BindError{}, err) {
+		be := err.(BindError)
+		assert.Equal(t, "id", be.Field)
+		assert.Equal(t, "json", be.Source)
+		assert.Equal(t, "string", be.Value)
+		assert.Equal(t, "json: cannot unmarshal string into Go struct field user.id of type int", be.Err.Error())
+	}
+}
+
+func TestBindRequest(t *testing.T) {
+	type Request struct {
+		ID     int    `path:"id" json:"id"`
+		Page   int    `query:"page"`
+		Tenant string `header:"X-Tenant"`
+		SID    string `cookie:"sid"`
+		Name   string `json:"name"`
+	}
+
+	s := New()
+	s.Route("/users/:id").POST(func(ctx *Context) error {
+		var req Request
+		if err := ctx.BindRequest(&req); err != nil {
+			return err
+		}
+		return ctx.JSON(200, req)
+	})
+
+	body := strings.NewReader(`{"id":1,"name":"Aaron","Page":3}`)
+	req := httptest.NewRequest(http.MethodPost, "/users/2?page=4&Tenant=t2", body)
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	req.Header.Set("X-Tenant", "t1")
+	req.AddCookie(&http.Cookie{Name: "sid", Value: "s1"})
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `{"id":2,"Page":4,"Tenant":"t1","SID":"s1","name":"Aaron"}`, rec.Body.String())
+}
+
+func TestBindURLValuesNested(t *testing.T) {
+	type Address struct {
+		City   string `query:"city"`
+		Street string
+	}
+	type Item struct {
+		SKU   string `query:"sku"`
+		Count int    `query:"count"`
+	}
+	var v struct {
+		Address Address  `query:"address"`
+		Home    *Address `query:"home"`
+		Work    Address
+		Items   []Item            `query:"items"`
+		Tags    []string          `query:"tags"`
+		Filter  map[string]string `query:"filter"`
+		Ranges  map[string][]int  `query:"ranges"`
+	}
+
+	data, _ := url.ParseQuery("address.city=X&address[street]=S&home.city=H&" +
+		"work.city=W&items[0].sku=A&items[1][sku]=B&items[1].count=2&" +
+		"tags[1]=b&tags[0]=a&filter[status]=open&filter.user=me&ranges[age][1]=30")
+	if assert.NoError(t, BindURLValues(&v, data, "query")) {
+		assert.Equal(t, Address{City: "X", Street: "S"}, v.Address)
+		assert.Equal(t, &Address{City: "H"}, v.Home)
+		assert.Equal(t, Address{City: "W"}, v.Work)
+		assert.Equal(t, []Item{{SKU: "A"}, {SKU: "B", Count: 2}}, v.Items)
+		assert.Equal(t, []string{"a", "b"}, v.Tags)
+		assert.Equal(t, map[string]string{"status": "open", "user": "me"}, v.Filter)
+		assert.Equal(t, map[string][]int{"age": {0, 30}}, v.Ranges)
+	}
+
+	// Only bind the tagged fields of the nested structs.
+	var tagged struct {
+		Address Address `query:"address"`
+		Work    Address
+	}
+	data, _ = url.ParseQuery("address.city=X&address.Street=S&work.city=W")
+	if assert.NoError(t, BindURLValuesByTag(&tagged, data, "query")) {
+		assert.Equal(t, Address{City: "X"}, tagged.Address)
+		assert.Equal(t, Address{}, tagged.Work)
+	}
+
+	data, _ = url.ParseQuery("items[1000].sku=A")
+	assert.Error(t, BindURLValues(&v, data, "query"))
+	data, _ = url.ParseQuery("items[x].sku=A")
+	assert.Error(t, BindURLValues(&v, data, "query"))
+
+	var deep struct {
+		M map[string]map[string]map[string]string `query:"m"`
+	}
+	data, _ = url.ParseQuery("m.a.b.c=1")
+	if assert.NoError(t, BindURLValues(&deep, data, "query")) {
+		assert.Equal(t, "1", deep.M["a"]["b"]["c"])
+	}
+
+	s := New(SetMaxBindDepth(2), SetMaxBindIndex(1000))
+	req := httptest.NewRequest(http.MethodGet, "/?m.a.b.c=1", nil)
+	ctx := s.AcquireContext(req, httptest.NewRecorder())
+	assert.Error(t, ctx.BindQuery(&deep))
+	s.ReleaseContext(ctx)
+
+	req = httptest.NewRequest(http.MethodGet, "/?items[1000].sku=A", nil)
+	ctx = s.AcquireContext(req, httptest.NewRecorder())
+	if assert.NoError(t, ctx.BindQuery(&v)) {
+		assert.Len(t, v.Items, 1001)
+		assert.Equal(t, "A", v.Items[1000].SKU)
+	}
+	s.ReleaseContext(ctx)
+}
+
+func TestBindURLValuesDefaultAndLayout(t *testing.T) {
+	type Request struct {
+		Page    int           `query:"page" default:"1"`
+		Size    int           `query:"size" default:"20"`
+		Tags    []string      `query:"tags" default:"a,b"`
+		Since   time.Time     `query:"since" layout:"2006-01-02"`
+		Until   *time.Time    `query:"until"`
+		Timeout time.Duration `query:"timeout" default:"5s"`
+		Limit   *int          `query:"limit"`
+		Offset  *uint         `query:"offset"`
+	}
+
+	var req Request
+	req.Size = 10
+	data, _ := url.ParseQuery("since=2024-01-02&until=2024-01-03T04:05:06Z&limit=3")
+	if assert.NoError(t, BindURLValues(&req, data, "query")) {
+		assert.Equal(t, 1, req.Page)
+		assert.Equal(t, 10, req.Size)
+		assert.Equal(t, []string{"a", "b"}, req.Tags)
+		assert.Equal(t, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), req.Since)
+		assert.Equal(t, time.Date(2024, 1, 3, 4, 5, 6, 0, time.UTC), *req.Until)
+		assert.Equal(t, time.Second*5, req.Timeout)
+		assert.Equal(t, 3, *req.Limit)
+		assert.Nil(t, req.Offset)
+	}
+
+	data, _ = url.ParseQuery("timeout=abc")
+	err := BindURLValues(&Request{}, data, "query")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "'Timeout'")
+	}
+
+	data, _ = url.ParseQuery("since=2024/01/02")
+	err = BindURLValues(&Request{}, data, "query")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "'Since'")
+	}
+}
+
+func TestBindError(t *testing.T) {
+	type Item struct {
+		Count int `query:"count"`
+	}
+	var v struct {
+		Items []Item          `query:"items"`
+		Flags map[string]bool `query:"flags"`
+	}
+
+	data, _ := url.ParseQuery("items[1].count=abc")
+	err := BindURLValues(&v, data, "query")
+	if assert.IsType(t, BindError{}, err) {
+		be := err.(BindError)
+		assert.Equal(t, "Items[1].Count", be.Field)
+		assert.Equal(t, "query", be.Source)
+		assert.Equal(t, "abc", be.Value)
+		assert.Error(t, be.Err)
+	}
+
+	data, _ = url.ParseQuery("flags[on]=maybe")
+	err = BindURLValues(&v, data, "query")
+	if assert.IsType(t, BindError{}, err) {
+		assert.Equal(t, "Flags[on]", err.(BindError).Field)
+	}
+
+	s := New()
+	s.Route("/:id").GET(func(ctx *Context) error {
+		var v struct {
+			ID int `url:"id"`
+		}
+		return ctx.ParamToStruct(&v)
+	})
+	s.Route("/").GET(func(ctx *Context) error {
+		var v struct {
+			Page int `query:"page"`
+		}
+		return ctx.BindQuery(&v)
+	})
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/abc", nil)
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "'ID'")
+
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/?page=x", nil)
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "'Page' from the query")
+}
+
+func TestJSONBinderOptions(t *testing.T) {
+	bind := func(binder Binder, body string, v interface{}) error {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+		return binder.Bind(New().NewContext(req, httptest.NewRecorder()), v)
+	}
+
+	var u user
+	assert.NoError(t, bind(JSONBinder(), `{"id":1,"age":2}{}`, &u))
+
+	binder := JSONBinder(JSONDisallowUnknownFields())
+	err := bind(binder, `{"id":1,"age":2}`, &u)
+	if assert.IsType(t, BindError{}, err) {
+		assert.Equal(t, "age", err.(BindError).Field)
+	}
+
+	binder = JSONBinder(JSONDisallowTrailingData())
+	assert.NoError(t, bind(binder, `{"id":1} `, &u))
+	for _, body := range []string{`{"id":1}{}`, `{"id":1}x`, `{"id":1}]`} {
+		err = bind(binder, body, &u)
+		if assert.IsType(t, BindError{}, err, body) {
+			assert.Equal(t, ErrTrailingData, err.(BindError).Err)
+		}
+	}
+
+	var v map[string]interface{}
+	binder = JSONBinder(JSONUseNumber())
+	if assert.NoError(t, bind(binder, `{"id":12345678901234567890}`, &v)) {
+		assert.Equal(t, json.Number("12345678901234567890"), v["id"])
+	}
+
+	// The request body is too large.
+	s := New(SetBinder(JSONBinder(JSONDisallowTrailingData())))
+	s.Route("/").POST(func(ctx *Context) error {
+		ctx.Request().Body = http.MaxBytesReader(ctx.Response(), ctx.Request().Body, 9)
+		return ctx.Bind(&u)
+	})
+
+	for body, code := range map[string]int{
+		`{"id":1,"name":"Aaron"}`: http.StatusRequestEntityTooLarge,
+		`{"id":1}     ` + "\n\n":  http.StatusRequestEntityTooLarge,
+		`{"id":1}x`:               http.StatusBadRequest,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		assert.Equal(t, code, rec.Code, body)
+	}
 }
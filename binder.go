@@ -18,6 +18,8 @@ import (
 	"encoding/json"
 	"encoding/xml"
 	"errors"
+	"io"
+	"strings"
 )
 
 // Binder is the interface to bind the value to v from ctx.
@@ -71,6 +73,20 @@ func (mb *MuxBinder) Bind(ctx *Context, v interface{}) error {
 	return ErrUnsupportedMediaType.NewMsg("not support Content-Type '%s'", ct)
 }
 
+// newBodyBindError returns the error to read the request body as it is
+// if it is an HTTPError, or returns the BindError.
+func newBodyBindError(be BindError) error {
+	switch err := be.Err.(type) {
+	case HTTPError:
+		return err
+	default:
+		if isMaxBytesError(err) {
+			return ErrStatusRequestEntityTooLarge.NewError(err)
+		}
+		return be
+	}
+}
+
 type binderFunc func(*Context, interface{}) error
 
 func (f binderFunc) Bind(ctx *Context, v interface{}) error {
@@ -82,22 +98,103 @@ func BinderFunc(f func(*Context, interface{}) error) Binder {
 	return binderFunc(f)
 }
 
+// ErrTrailingData is returned when there is the data following the bound value.
+var ErrTrailingData = errors.New("invalid data after the top-level value")
+
+type jsonBinderOption struct {
+	disallowUnknownFields bool
+	disallowTrailingData  bool
+	useNumber             bool
+}
+
+// JSONBinderOption is used to configure the JSON binder.
+type JSONBinderOption func(*jsonBinderOption)
+
+// JSONDisallowUnknownFields returns a JSON binder option to reject the JSON
+// object keys that do not match any exported field of the bound struct.
+func JSONDisallowUnknownFields() JSONBinderOption {
+	return func(o *jsonBinderOption) { o.disallowUnknownFields = true }
+}
+
+// JSONDisallowTrailingData returns a JSON binder option to reject the data
+// following the first JSON value, such as `{"id":1}{"id":2}` or `{"id":1}x`.
+func JSONDisallowTrailingData() JSONBinderOption {
+	return func(o *jsonBinderOption) { o.disallowTrailingData = true }
+}
+
+// JSONUseNumber returns a JSON binder option to decode a number
+// into an interface{} as a json.Number instead of as a float64.
+func JSONUseNumber() JSONBinderOption {
+	return func(o *jsonBinderOption) { o.useNumber = true }
+}
+
 // JSONBinder returns a JSON binder to bind the JSON request.
-func JSONBinder() Binder {
+//
+// If failing to decode the body, it returns BindError, except the error
+// returned by the body itself as HTTPError, such as the 413 error returned by
+// the middleware BodyLimit, which is returned as it is. For example,
+//
+//     mb.Add(MIMEApplicationJSON, JSONBinder(JSONDisallowUnknownFields(),
+//         JSONDisallowTrailingData(), JSONUseNumber()))
+//
+func JSONBinder(options ...JSONBinderOption) Binder {
+	var opt jsonBinderOption
+	for _, option := range options {
+		option(&opt)
+	}
+
 	return BinderFunc(func(ctx *Context, v interface{}) error {
-		return json.NewDecoder(ctx.req.Body).Decode(v)
+		dec := json.NewDecoder(ctx.req.Body)
+		if opt.disallowUnknownFields {
+			dec.DisallowUnknownFields()
+		}
+		if opt.useNumber {
+			dec.UseNumber()
+		}
+
+		if err := dec.Decode(v); err != nil {
+			be := BindError{Source: "json", Err: err}
+			switch e := err.(type) {
+			case *json.UnmarshalTypeError:
+				be.Field, be.Value = e.Field, e.Value
+			default:
+				// For the error like 'json: unknown field "name"'.
+				const prefix = `json: unknown field "`
+				if msg := err.Error(); strings.HasPrefix(msg, prefix) {
+					be.Field = strings.TrimSuffix(msg[len(prefix):], `"`)
+				}
+			}
+			return newBodyBindError(be)
+		}
+
+		if opt.disallowTrailingData {
+			if _, err := dec.Token(); err != io.EOF {
+				if _, ok := err.(*json.SyntaxError); ok || err == nil {
+					err = ErrTrailingData
+				}
+				return newBodyBindError(BindError{Source: "json", Err: err})
+			}
+		}
+
+		return nil
 	})
 }
 
 // XMLBinder returns a XML binder to bind the XML request.
 func XMLBinder() Binder {
 	return BinderFunc(func(ctx *Context, v interface{}) error {
-		return xml.NewDecoder(ctx.req.Body).Decode(v)
+		if err := xml.NewDecoder(ctx.req.Body).Decode(v); err != nil {
+			return newBodyBindError(BindError{Source: "xml", Err: err})
+		}
+		return nil
 	})
 }
 
 // FormBinder returns a Form binder to bind the Form request.
 //
+// The nested keys are bound with the limits set by SetMaxBindIndex
+// and SetMaxBindDepth. See BindURLValues.
+//
 // Notice: The bound value must be a pointer to a struct.
 // You can modify the name of the field by the tag, which is "form" by default.
 func FormBinder(tag ...string) Binder {
@@ -109,9 +206,9 @@ func FormBinder(tag ...string) Binder {
 	return BinderFunc(func(ctx *Context, v interface{}) error {
 		form, err := ctx.FormParams()
 		if err != nil {
-			return err
+			return newBodyBindError(BindError{Source: "form", Err: err})
 		}
-		return BindURLValues(v, form, _tag)
+		return ctx.bindURLValues(v, form, _tag, false)
 	})
 }
 
@@ -126,6 +223,6 @@ func QueryBinder(tag ...string) Binder {
 	}
 
 	return BinderFunc(func(ctx *Context, v interface{}) error {
-		return BindURLValues(v, ctx.QueryParams(), _tag)
+		return ctx.bindURLValues(v, ctx.QueryParams(), _tag, false)
 	})
 }
@@ -24,10 +24,12 @@ package ship
 
 import (
 	"errors"
+	"fmt"
 	"net/url"
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // BindUnmarshaler is the interface used to wrap the UnmarshalParam method.
@@ -36,17 +38,70 @@ type BindUnmarshaler interface {
 	UnmarshalBind(param string) error
 }
 
+// bindLimits is the limits of binding the nested keys.
+type bindLimits struct {
+	// maxIndex is the maximum index of the slice element when binding
+	// the indexed key, such as "items[100].sku", which is used to prevent
+	// a hostile request from allocating a huge slice.
+	maxIndex int
+
+	// maxDepth is the maximum depth of the nested key when binding,
+	// such as "a.b.c" whose depth is 2.
+	maxDepth int
+}
+
+var defaultBindLimits = bindLimits{maxIndex: 256, maxDepth: 8}
+
 // BindURLValues parses the data and assign to the pointer ptr to a struct.
 //
 // Notice: tag is the name of the struct tag. such as "form", "query", etc.
+// If the tag of a field is missing, the field name will be used.
+//
+// The key may be in the dot or bracket notation to bind the nested struct,
+// the slice of struct and the map, for example,
+//
+//     address.city=X      // Bind to the field "City" of the field "Address".
+//     items[0].sku=Y      // Bind to the field "SKU" of the first element of "Items".
+//     filter[status]=open // Bind to the key "status" of the map "Filter".
+//
+// The index of the slice must not be greater than 256, and the depth
+// of the key must not be greater than 8. The binders of Ship use the limits
+// set by SetMaxBindIndex and SetMaxBindDepth instead.
+//
+// If the key is absent and the field is the zero value, the value of the tag
+// "default" will be used, which is split by the comma for the slice field.
+// time.Time is parsed with the layout in the tag "layout", which is
+// time.RFC3339 by default, and time.Duration is parsed by time.ParseDuration.
+// For example,
+//
+//     type Request struct {
+//         Page    int           `query:"page" default:"1"`
+//         Since   time.Time     `query:"since" layout:"2006-01-02"`
+//         Timeout time.Duration `query:"timeout" default:"5s"`
+//         Limit   *int          `query:"limit"`
+//     }
 func BindURLValues(ptr interface{}, data url.Values, tag string) error {
-	typ := reflect.TypeOf(ptr).Elem()
-	val := reflect.ValueOf(ptr).Elem()
+	return bindURLValues(ptr, data, tag, false, defaultBindLimits)
+}
 
-	if typ.Kind() != reflect.Struct {
+// BindURLValuesByTag is the same as BindURLValues, but only binds the fields
+// which have the tag and ignores others, including those of the nested structs.
+func BindURLValuesByTag(ptr interface{}, data url.Values, tag string) error {
+	return bindURLValues(ptr, data, tag, true, defaultBindLimits)
+}
+
+func bindURLValues(ptr interface{}, data url.Values, tag string, onlyTag bool,
+	limits bindLimits) error {
+	val := reflect.ValueOf(ptr).Elem()
+	if val.Kind() != reflect.Struct {
 		return errors.New("binding element must be a struct")
 	}
+	return bindStruct(val, data, tag, "", onlyTag, 0, limits)
+}
 
+func bindStruct(val reflect.Value, data url.Values, tag, path string,
+	onlyTag bool, depth int, limits bindLimits) error {
+	typ := val.Type()
 	for i := 0; i < typ.NumField(); i++ {
 		typeField := typ.Field(i)
 		structField := val.Field(i)
@@ -55,16 +110,31 @@ func BindURLValues(ptr interface{}, data url.Values, tag string) error {
 		}
 		structFieldKind := structField.Kind()
 		inputFieldName := typeField.Tag.Get(tag)
+		fieldPath := joinFieldPath(path, typeField.Name)
 
 		if inputFieldName == "" {
 			inputFieldName = typeField.Name
 			// If tag is nil, we inspect if the field is a struct.
-			if _, ok := bindUnmarshaler(structField); !ok && structFieldKind == reflect.Struct {
-				if err := BindURLValues(structField.Addr().Interface(), data, tag); err != nil {
+			if structFieldKind == reflect.Struct && !isBindScalar(structField) {
+				if err := bindStruct(structField, data, tag, fieldPath, onlyTag, depth, limits); err != nil {
 					return err
 				}
+
+				// Also bind the nested keys prefixed with the field name,
+				// such as "Address.City", unless only binding by the tag.
+				if onlyTag {
+					continue
+				} else if sub := subURLValues(data, inputFieldName); len(sub) > 0 {
+					if err := bindNested(structField, sub, tag, fieldPath, onlyTag, depth+1, limits); err != nil {
+						return err
+					}
+				}
+				continue
+			} else if onlyTag {
 				continue
 			}
+		} else if inputFieldName == "-" {
+			continue
 		}
 
 		inputValue, exists := data[inputFieldName]
@@ -73,9 +143,9 @@ func BindURLValues(ptr interface{}, data url.Values, tag string) error {
 			// url params are bound case sensitive which is inconsistent.  To
 			// fix this we must check all of the map values in a
 			// case-insensitive search.
-			inputFieldName = strings.ToLower(inputFieldName)
+			lowerName := strings.ToLower(inputFieldName)
 			for k, v := range data {
-				if strings.ToLower(k) == inputFieldName {
+				if strings.ToLower(k) == lowerName {
 					inputValue = v
 					exists = true
 					break
@@ -84,44 +154,239 @@ func BindURLValues(ptr interface{}, data url.Values, tag string) error {
 		}
 
 		if !exists {
-			continue
+			if sub := subURLValues(data, inputFieldName); len(sub) > 0 {
+				if err := bindNested(structField, sub, tag, fieldPath, onlyTag, depth+1, limits); err != nil {
+					return err
+				}
+				continue
+			}
+
+			def, ok := typeField.Tag.Lookup("default")
+			if !ok || !isZero(structField) {
+				continue
+			} else if structFieldKind == reflect.Slice {
+				inputValue = strings.Split(def, ",")
+			} else {
+				inputValue = []string{def}
+			}
+		}
+
+		layout := typeField.Tag.Get("layout")
+		if err := setURLValues(structField, inputValue, layout); err != nil {
+			return BindError{Field: fieldPath, Source: tag, Value: inputValue[0], Err: err}
 		}
+	}
+
+	return nil
+}
 
-		// Call this first, in case we're dealing with an alias to an array type
-		if ok, err := unmarshalField(typeField.Type.Kind(), inputValue[0], structField); ok {
-			if err != nil {
+// isBindScalar reports whether the struct field is bound as a single value,
+// not as a nested struct.
+func isBindScalar(field reflect.Value) bool {
+	if _, ok := bindUnmarshaler(field); ok {
+		return true
+	}
+	return field.Type() == timeType
+}
+
+// setURLValues sets the field to the values, which may be a slice.
+func setURLValues(field reflect.Value, values []string, layout string) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	// Call this first, in case we're dealing with an alias to an array type
+	if ok, err := unmarshalField(field.Kind(), values[0], field); ok {
+		return err
+	}
+
+	if field.Kind() == reflect.Slice {
+		sliceOf := field.Type().Elem().Kind()
+		slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+		for j := range values {
+			if err := setWithProperType(sliceOf, values[j], slice.Index(j), layout); err != nil {
 				return err
 			}
-			continue
 		}
+		field.Set(slice)
+		return nil
+	}
 
-		numElems := len(inputValue)
-		if structFieldKind == reflect.Slice && numElems > 0 {
-			sliceOf := structField.Type().Elem().Kind()
-			slice := reflect.MakeSlice(structField.Type(), numElems, numElems)
-			for j := 0; j < numElems; j++ {
-				if err := setWithProperType(sliceOf, inputValue[j], slice.Index(j)); err != nil {
-					return err
-				}
+	return setWithProperType(field.Kind(), values[0], field, layout)
+}
+
+// bindNested binds the nested values, the keys of which have been stripped
+// the prefix of the field name, to the struct, the slice or the map field.
+func bindNested(field reflect.Value, data url.Values, tag, path string,
+	onlyTag bool, depth int, limits bindLimits) error {
+	if depth > limits.maxDepth {
+		err := fmt.Errorf("the depth of the key exceeds the limit %d", limits.maxDepth)
+		return BindError{Field: path, Source: tag, Err: err}
+	}
+
+	switch field.Kind() {
+	case reflect.Ptr:
+		if field.IsNil() {
+			field.Set(reflect.New(field.Type().Elem()))
+		}
+		return bindNested(field.Elem(), data, tag, path, onlyTag, depth, limits)
+
+	case reflect.Struct:
+		return bindStruct(field, data, tag, path, onlyTag, depth, limits)
+
+	case reflect.Slice:
+		indexes := make(map[int]string, len(data))
+		max := -1
+		for key := range data {
+			first, _ := splitURLKey(key)
+			index, err := strconv.Atoi(first)
+			if err != nil || index < 0 {
+				err = fmt.Errorf("invalid slice index '%s'", first)
+				return BindError{Field: path, Source: tag, Value: first, Err: err}
+			} else if index > limits.maxIndex {
+				err = fmt.Errorf("the slice index exceeds the limit %d", limits.maxIndex)
+				return BindError{Field: path, Source: tag, Value: first, Err: err}
+			} else if index > max {
+				max = index
 			}
-			val.Field(i).Set(slice)
-		} else if err := setWithProperType(typeField.Type.Kind(), inputValue[0], structField); err != nil {
-			return err
+			indexes[index] = first
+		}
+
+		if field.Len() <= max {
+			slice := reflect.MakeSlice(field.Type(), max+1, max+1)
+			reflect.Copy(slice, field)
+			field.Set(slice)
 		}
+
+		for index, key := range indexes {
+			if err := bindElem(field.Index(index), data, key, tag, path, onlyTag, depth, limits); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Map:
+		typ := field.Type()
+		if typ.Key().Kind() != reflect.String {
+			return fmt.Errorf("unsupported map key type '%s'", typ.Key())
+		} else if field.IsNil() {
+			field.Set(reflect.MakeMap(typ))
+		}
+
+		keys := make(map[string]struct{}, len(data))
+		for key := range data {
+			first, _ := splitURLKey(key)
+			keys[first] = struct{}{}
+		}
+
+		for key := range keys {
+			mkey := reflect.ValueOf(key).Convert(typ.Key())
+			elem := reflect.New(typ.Elem()).Elem()
+			if v := field.MapIndex(mkey); v.IsValid() {
+				elem.Set(v)
+			}
+
+			if err := bindElem(elem, data, key, tag, path, onlyTag, depth, limits); err != nil {
+				return err
+			}
+			field.SetMapIndex(mkey, elem)
+		}
+
+	default: // Ignore the nested keys for other types, like the unknown keys.
 	}
 
 	return nil
 }
 
-func setWithProperType(valueKind reflect.Kind, val string, structField reflect.Value) error {
+// bindElem binds the values of the key and its nested keys to the element
+// of the slice or the map.
+func bindElem(elem reflect.Value, data url.Values, key, tag, path string,
+	onlyTag bool, depth int, limits bindLimits) error {
+	path = path + "[" + key + "]"
+	if values, ok := data[key]; ok {
+		if err := setURLValues(elem, values, ""); err != nil {
+			return BindError{Field: path, Source: tag, Value: values[0], Err: err}
+		}
+	}
+
+	if sub := subURLValues(data, key); len(sub) > 0 {
+		return bindNested(elem, sub, tag, path, onlyTag, depth+1, limits)
+	}
+	return nil
+}
+
+func joinFieldPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// splitURLKey splits the key into the first segment and the rest,
+// for example, "items[0].sku" is split into "items" and "[0].sku",
+// and "[0].sku" is split into "0" and ".sku".
+func splitURLKey(key string) (first, rest string) {
+	if key != "" && key[0] == '[' {
+		if i := strings.IndexByte(key, ']'); i > 0 {
+			return key[1:i], key[i+1:]
+		}
+		return key, ""
+	}
+
+	if i := strings.IndexAny(key, ".["); i > 0 {
+		return key[:i], key[i:]
+	}
+	return key, ""
+}
+
+// subURLValues returns the values whose keys are nested in the given name,
+// with the name stripped from the keys, such as "city" for "address.city".
+func subURLValues(data url.Values, name string) url.Values {
+	var sub url.Values
+	for key, values := range data {
+		first, rest := splitURLKey(key)
+		if rest == "" || !strings.EqualFold(first, name) {
+			continue
+		}
+
+		if rest[0] == '.' {
+			rest = rest[1:]
+		} else if f, r := splitURLKey(rest); f != rest { // "[0].sku" => "0.sku"
+			rest = f + r
+		}
+
+		if sub == nil {
+			sub = make(url.Values, len(data))
+		}
+		sub[rest] = append(sub[rest], values...)
+	}
+	return sub
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+func setWithProperType(valueKind reflect.Kind, val string, structField reflect.Value,
+	layout string) error {
 	// But also call it here, in case we're dealing with an array of BindUnmarshalers
 	if ok, err := unmarshalField(valueKind, val, structField); ok {
 		return err
 	}
 
+	switch structField.Type() {
+	case timeType:
+		return setTimeField(val, layout, structField)
+	case durationType:
+		return setDurationField(val, structField)
+	}
+
 	switch valueKind {
 	case reflect.Ptr:
-		return setWithProperType(structField.Elem().Kind(), val, structField.Elem())
+		if structField.IsNil() {
+			structField.Set(reflect.New(structField.Type().Elem()))
+		}
+		return setWithProperType(structField.Elem().Kind(), val, structField.Elem(), layout)
 	case reflect.Int:
 		return setIntField(val, 0, structField)
 	case reflect.Int8:
@@ -237,3 +502,29 @@ func setFloatField(value string, bitSize int, field reflect.Value) error {
 	}
 	return err
 }
+
+func setTimeField(value, layout string, field reflect.Value) error {
+	if value == "" {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	} else if layout == "" {
+		layout = time.RFC3339
+	}
+
+	timeVal, err := time.Parse(layout, value)
+	if err == nil {
+		field.Set(reflect.ValueOf(timeVal))
+	}
+	return err
+}
+
+func setDurationField(value string, field reflect.Value) error {
+	if value == "" {
+		value = "0s"
+	}
+	durationVal, err := time.ParseDuration(value)
+	if err == nil {
+		field.SetInt(int64(durationVal))
+	}
+	return err
+}
@@ -0,0 +1,59 @@
+// Copyright 2019 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cbor supplies the binder and the renderer of CBOR.
+//
+// It uses the third-party package, github.com/fxamacker/cbor, to implement it.
+package cbor
+
+import (
+	"github.com/fxamacker/cbor/v2"
+	"github.com/xgfone/ship"
+)
+
+// MIMEApplicationCBOR is the MIME type of CBOR.
+const MIMEApplicationCBOR = ship.MIMEApplicationCBOR
+
+// Binder returns a CBOR binder to bind the CBOR request body.
+func Binder() ship.Binder {
+	return ship.BinderFunc(func(ctx *ship.Context, v interface{}) error {
+		if err := cbor.NewDecoder(ctx.Request().Body).Decode(v); err != nil {
+			return ship.BindError{Source: "cbor", Err: err}
+		}
+		return nil
+	})
+}
+
+// Renderer returns a CBOR renderer.
+//
+// Notice: the renderer name must be "cbor".
+func Renderer() ship.Renderer {
+	return ship.SimpleRenderer("cbor", MIMEApplicationCBOR, cbor.Marshal)
+}
+
+// Register registers the CBOR binder into the MuxBinder of s, and the CBOR
+// renderer named "cbor" into the MuxRenderer of s, which is used to negotiate
+// for the media type "application/cbor".
+//
+// If the binder or the renderer of s is not the Mux one, it is ignored.
+func Register(s *ship.Ship) {
+	if mb := s.MuxBinder(); mb != nil {
+		mb.Add(MIMEApplicationCBOR, Binder())
+	}
+
+	if mr := s.MuxRenderer(); mr != nil {
+		mr.Add("cbor", Renderer())
+		mr.AddMediaType(MIMEApplicationCBOR, "cbor")
+	}
+}
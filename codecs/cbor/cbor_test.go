@@ -0,0 +1,90 @@
+// Copyright 2019 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cbor
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/xgfone/ship"
+)
+
+// user uses the integer keys, which are more compact than the string keys.
+type user struct {
+	ID   int    `cbor:"1,keyasint"`
+	Name string `cbor:"2,keyasint"`
+	Data []byte `cbor:"3,keyasint,omitempty"`
+}
+
+func TestBindAndRender(t *testing.T) {
+	s := ship.New()
+	Register(s)
+	s.Route("/").POST(func(ctx *ship.Context) error {
+		var u user
+		if err := ctx.Bind(&u); err != nil {
+			return err
+		}
+		u.ID++
+		return ctx.Negotiate(200, u)
+	})
+
+	body, _ := cbor.Marshal(map[int]interface{}{1: 1, 2: "Aaron", 3: []byte{0xff}})
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	req.Header.Set(ship.HeaderContentType, MIMEApplicationCBOR)
+	req.Header.Set(ship.HeaderAccept, MIMEApplicationCBOR)
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, MIMEApplicationCBOR, rec.Header().Get(ship.HeaderContentType))
+
+	var m map[int]interface{}
+	if assert.NoError(t, cbor.Unmarshal(rec.Body.Bytes(), &m)) {
+		assert.Equal(t, map[int]interface{}{1: uint64(2), 2: "Aaron", 3: []byte{0xff}}, m)
+	}
+}
+
+func TestBindError(t *testing.T) {
+	s := ship.New()
+	Register(s)
+
+	bind := func(body []byte) (u user, err error) {
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+		req.Header.Set(ship.HeaderContentType, MIMEApplicationCBOR)
+		ctx := s.AcquireContext(req, httptest.NewRecorder())
+		err = ctx.Bind(&u)
+		s.ReleaseContext(ctx)
+		return
+	}
+
+	// The string key "1" also matches the integer key 1.
+	body, _ := cbor.Marshal(map[string]interface{}{"1": 5})
+	if u, err := bind(body); assert.NoError(t, err) {
+		assert.Equal(t, 5, u.ID)
+	}
+
+	// 0xc1 0xff is the tag 1 followed by the unexpected break code,
+	// and the type of the value mismatches the field.
+	body, _ = cbor.Marshal(map[int]interface{}{1: "abc"})
+	for _, body := range [][]byte{{0xc1, 0xff}, body} {
+		_, err := bind(body)
+		if assert.IsType(t, ship.BindError{}, err) {
+			assert.Equal(t, "cbor", err.(ship.BindError).Source)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2019 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package msgpack supplies the binder and the renderer of MessagePack.
+//
+// It uses the third-party package, github.com/vmihailenco/msgpack, to implement it.
+package msgpack
+
+import (
+	"github.com/vmihailenco/msgpack/v4"
+	"github.com/xgfone/ship"
+)
+
+// MIME types of MessagePack.
+const (
+	MIMEApplicationMsgpack  = ship.MIMEApplicationMsgpack
+	MIMEApplicationXMsgpack = "application/x-msgpack"
+)
+
+// Binder returns a MessagePack binder to bind the MessagePack request body.
+func Binder() ship.Binder {
+	return ship.BinderFunc(func(ctx *ship.Context, v interface{}) error {
+		if err := msgpack.NewDecoder(ctx.Request().Body).Decode(v); err != nil {
+			return ship.BindError{Source: "msgpack", Err: err}
+		}
+		return nil
+	})
+}
+
+// Renderer returns a MessagePack renderer.
+//
+// Notice: the renderer name must be "msgpack".
+func Renderer() ship.Renderer {
+	return ship.SimpleRenderer("msgpack", MIMEApplicationMsgpack, msgpack.Marshal)
+}
+
+// Register registers the MessagePack binder for the Content-Types of
+// MessagePack into the MuxBinder of s, and the MessagePack renderer named
+// "msgpack" into the MuxRenderer of s, which is used to negotiate for
+// the media types "application/msgpack" and "application/x-msgpack".
+//
+// If the binder or the renderer of s is not the Mux one, it is ignored.
+func Register(s *ship.Ship) {
+	if mb := s.MuxBinder(); mb != nil {
+		binder := Binder()
+		mb.Add(MIMEApplicationMsgpack, binder)
+		mb.Add(MIMEApplicationXMsgpack, binder)
+	}
+
+	if mr := s.MuxRenderer(); mr != nil {
+		mr.Add("msgpack", Renderer())
+		mr.AddMediaType(MIMEApplicationMsgpack, "msgpack")
+		mr.AddMediaType(MIMEApplicationXMsgpack, "msgpack")
+	}
+}
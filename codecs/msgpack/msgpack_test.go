@@ -0,0 +1,83 @@
+// Copyright 2019 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msgpack
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v4"
+	"github.com/xgfone/ship"
+)
+
+func TestBindAndRender(t *testing.T) {
+	s := ship.New()
+	Register(s)
+	s.Route("/").POST(func(ctx *ship.Context) error {
+		var v map[string]interface{}
+		if err := ctx.Bind(&v); err != nil {
+			return err
+		}
+
+		// The integer in interface{} is decoded as the type of its encoding,
+		// such as int8 or uint16 for the compact encoding.
+		id, ok := v["id"].(int8)
+		if !ok {
+			return ctx.String(http.StatusInternalServerError, "%T", v["id"])
+		}
+		v["id"] = int(id) + 1
+		return ctx.Negotiate(200, v)
+	})
+
+	body := new(bytes.Buffer)
+	enc := msgpack.NewEncoder(body).UseCompactEncoding(true)
+	enc.Encode(map[string]interface{}{"id": 1, "port": 8080})
+	req := httptest.NewRequest(http.MethodPost, "/", body)
+	req.Header.Set(ship.HeaderContentType, MIMEApplicationXMsgpack)
+	req.Header.Set(ship.HeaderAccept, MIMEApplicationXMsgpack)
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, MIMEApplicationMsgpack, rec.Header().Get(ship.HeaderContentType))
+
+	var v map[string]interface{}
+	if assert.NoError(t, msgpack.Unmarshal(rec.Body.Bytes(), &v)) {
+		// msgpack.Marshal encodes int as int64 without the compact encoding.
+		assert.Equal(t, map[string]interface{}{"id": int64(2), "port": uint16(8080)}, v)
+	}
+}
+
+func TestBindError(t *testing.T) {
+	s := ship.New()
+	Register(s)
+
+	var v struct {
+		ID int `msgpack:"id"`
+	}
+	mismatch, _ := msgpack.Marshal(map[string]interface{}{"id": "abc"})
+	for _, body := range [][]byte{{0xc1}, mismatch} { // 0xc1 is never used.
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+		req.Header.Set(ship.HeaderContentType, MIMEApplicationMsgpack)
+		ctx := s.AcquireContext(req, httptest.NewRecorder())
+		err := ctx.Bind(&v)
+		if assert.IsType(t, ship.BindError{}, err) {
+			assert.Equal(t, "msgpack", err.(ship.BindError).Source)
+		}
+		s.ReleaseContext(ctx)
+	}
+}
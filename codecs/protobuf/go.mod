@@ -0,0 +1,17 @@
+module github.com/xgfone/ship/codecs/protobuf
+
+go 1.18
+
+require (
+	github.com/stretchr/testify v1.8.2
+	github.com/xgfone/ship v0.0.0
+	google.golang.org/protobuf v1.31.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/xgfone/ship => ../..
@@ -0,0 +1,89 @@
+// Copyright 2019 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package protobuf supplies the binder and the renderer of Protocol Buffers.
+//
+// It uses the third-party package, google.golang.org/protobuf, to implement it.
+package protobuf
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/xgfone/ship"
+	"google.golang.org/protobuf/proto"
+)
+
+// MIME types of Protocol Buffers.
+const (
+	MIMEApplicationProtobuf  = ship.MIMEApplicationProtobuf
+	MIMEApplicationXProtobuf = "application/x-protobuf"
+)
+
+// Binder returns a Protocol Buffers binder to bind the request body.
+//
+// Notice: the bound value must be a proto.Message. Or return a BindError.
+func Binder() ship.Binder {
+	return ship.BinderFunc(func(ctx *ship.Context, v interface{}) error {
+		m, ok := v.(proto.Message)
+		if !ok {
+			err := fmt.Errorf("the value of the type '%T' is not a proto.Message", v)
+			return ship.BindError{Source: "protobuf", Err: err}
+		}
+
+		data, err := ioutil.ReadAll(ctx.Request().Body)
+		if err == nil {
+			err = proto.Unmarshal(data, m)
+		}
+		if err != nil {
+			return ship.BindError{Source: "protobuf", Err: err}
+		}
+		return nil
+	})
+}
+
+// Renderer returns a Protocol Buffers renderer.
+//
+// Notice: the renderer name must be "protobuf", and the rendered value
+// must be a proto.Message.
+func Renderer() ship.Renderer {
+	return ship.SimpleRenderer("protobuf", MIMEApplicationProtobuf, marshal)
+}
+
+func marshal(v interface{}) ([]byte, error) {
+	if m, ok := v.(proto.Message); ok {
+		return proto.Marshal(m)
+	}
+	return nil, fmt.Errorf("the value of the type '%T' is not a proto.Message", v)
+}
+
+// Register registers the Protocol Buffers binder for the Content-Types of
+// Protocol Buffers into the MuxBinder of s, and the Protocol Buffers renderer
+// named "protobuf" into the MuxRenderer of s, which is used to negotiate for
+// the media types "application/protobuf" and "application/x-protobuf".
+//
+// If the binder or the renderer of s is not the Mux one, it is ignored.
+func Register(s *ship.Ship) {
+	if mb := s.MuxBinder(); mb != nil {
+		binder := Binder()
+		mb.Add(MIMEApplicationProtobuf, binder)
+		mb.Add(MIMEApplicationXProtobuf, binder)
+	}
+
+	if mr := s.MuxRenderer(); mr != nil {
+		mr.Add("protobuf", Renderer())
+		mr.AddMediaType(MIMEApplicationProtobuf, "protobuf")
+		mr.AddMediaType(MIMEApplicationXProtobuf, "protobuf")
+	}
+}
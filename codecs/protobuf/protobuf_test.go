@@ -0,0 +1,98 @@
+// Copyright 2019 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protobuf
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xgfone/ship"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestBindAndRender(t *testing.T) {
+	s := ship.New()
+	Register(s)
+	s.Route("/").POST(func(ctx *ship.Context) error {
+		var v wrapperspb.StringValue
+		if err := ctx.Bind(&v); err != nil {
+			return err
+		}
+		v.Value += "!"
+		return ctx.Negotiate(200, &v)
+	})
+
+	body, _ := proto.Marshal(wrapperspb.String("Aaron"))
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	req.Header.Set(ship.HeaderContentType, MIMEApplicationXProtobuf)
+	req.Header.Set(ship.HeaderAccept, MIMEApplicationXProtobuf)
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, MIMEApplicationProtobuf, rec.Header().Get(ship.HeaderContentType))
+
+	var v wrapperspb.StringValue
+	if assert.NoError(t, proto.Unmarshal(rec.Body.Bytes(), &v)) {
+		assert.Equal(t, "Aaron!", v.Value)
+	}
+
+	// The empty body is the zero message.
+	req = httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set(ship.HeaderContentType, MIMEApplicationProtobuf)
+	req.Header.Set(ship.HeaderAccept, MIMEApplicationProtobuf)
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	if assert.NoError(t, proto.Unmarshal(rec.Body.Bytes(), &v)) {
+		assert.Equal(t, "!", v.Value)
+	}
+}
+
+func TestTypeMismatch(t *testing.T) {
+	s := ship.New()
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte{0x08, 0x01}))
+	req.Header.Set(ship.HeaderContentType, MIMEApplicationProtobuf)
+	ctx := s.AcquireContext(req, httptest.NewRecorder())
+	defer s.ReleaseContext(ctx)
+
+	// Not a proto.Message
+	var v struct{ Value string }
+	err := Binder().Bind(ctx, &v)
+	if assert.IsType(t, ship.BindError{}, err) {
+		assert.Equal(t, "protobuf", err.(ship.BindError).Source)
+	}
+	_, err = marshal(v)
+	assert.Error(t, err)
+
+	// The field 1 with the mismatched wire type 0 (varint) is kept
+	// as the unknown field, not an error.
+	var sv wrapperspb.StringValue
+	if assert.NoError(t, Binder().Bind(ctx, &sv)) {
+		assert.Equal(t, "", sv.Value)
+		assert.NotEmpty(t, sv.ProtoReflect().GetUnknown())
+	}
+
+	// But the string field must be the valid UTF-8.
+	ctx.Request().Body = ioutil.NopCloser(bytes.NewReader([]byte{0x0a, 0x01, 0xff}))
+	err = Binder().Bind(ctx, new(wrapperspb.StringValue))
+	if assert.IsType(t, ship.BindError{}, err) {
+		assert.Equal(t, "protobuf", err.(ship.BindError).Source)
+	}
+}
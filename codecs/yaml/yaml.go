@@ -0,0 +1,68 @@
+// Copyright 2019 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package yaml supplies the binder and the renderer of YAML.
+//
+// It uses the third-party package, gopkg.in/yaml.v2, to implement it.
+package yaml
+
+import (
+	"github.com/xgfone/ship"
+	goyaml "gopkg.in/yaml.v2"
+)
+
+// MIME types of YAML.
+const (
+	MIMEApplicationYAML  = ship.MIMEApplicationYAML
+	MIMEApplicationXYAML = "application/x-yaml"
+	MIMETextYAML         = "text/yaml"
+)
+
+// Binder returns a YAML binder to bind the YAML request body.
+func Binder() ship.Binder {
+	return ship.BinderFunc(func(ctx *ship.Context, v interface{}) error {
+		if err := goyaml.NewDecoder(ctx.Request().Body).Decode(v); err != nil {
+			return ship.BindError{Source: "yaml", Err: err}
+		}
+		return nil
+	})
+}
+
+// Renderer returns a YAML renderer.
+//
+// Notice: the renderer name must be "yaml".
+func Renderer() ship.Renderer {
+	return ship.SimpleRenderer("yaml", MIMEApplicationYAML, goyaml.Marshal)
+}
+
+// Register registers the YAML binder for the Content-Types of YAML into
+// the MuxBinder of s, and the YAML renderer named "yaml" into the MuxRenderer
+// of s, which is used to negotiate for the media types of YAML.
+//
+// If the binder or the renderer of s is not the Mux one, it is ignored.
+func Register(s *ship.Ship) {
+	if mb := s.MuxBinder(); mb != nil {
+		binder := Binder()
+		mb.Add(MIMEApplicationYAML, binder)
+		mb.Add(MIMEApplicationXYAML, binder)
+		mb.Add(MIMETextYAML, binder)
+	}
+
+	if mr := s.MuxRenderer(); mr != nil {
+		mr.Add("yaml", Renderer())
+		mr.AddMediaType(MIMEApplicationYAML, "yaml")
+		mr.AddMediaType(MIMEApplicationXYAML, "yaml")
+		mr.AddMediaType(MIMETextYAML, "yaml")
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2019 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yaml
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xgfone/ship"
+	goyaml "gopkg.in/yaml.v2"
+)
+
+func TestBindAndRender(t *testing.T) {
+	s := ship.New()
+	Register(s)
+	s.Route("/").POST(func(ctx *ship.Context) error {
+		var v map[string]interface{}
+		if err := ctx.Bind(&v); err != nil {
+			return err
+		}
+
+		// The nested map is decoded as map[interface{}]interface{}, which can
+		// be rendered as YAML, but not as JSON.
+		if _, ok := v["labels"].(map[interface{}]interface{}); !ok {
+			return ctx.String(http.StatusInternalServerError, "%T", v["labels"])
+		}
+		v["id"] = v["id"].(int) + 1
+		return ctx.Negotiate(200, v)
+	})
+
+	body := "id: 1\nlabels:\n  1: one\n  true: yes\n"
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set(ship.HeaderContentType, MIMETextYAML)
+	req.Header.Set(ship.HeaderAccept, MIMEApplicationXYAML)
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, MIMEApplicationYAML, rec.Header().Get(ship.HeaderContentType))
+
+	var v map[string]interface{}
+	if assert.NoError(t, goyaml.Unmarshal(rec.Body.Bytes(), &v)) {
+		assert.Equal(t, 2, v["id"])
+		assert.Equal(t, map[interface{}]interface{}{1: "one", true: true}, v["labels"])
+	}
+}
+
+func TestBindError(t *testing.T) {
+	s := ship.New()
+	Register(s)
+
+	var v struct {
+		ID int `yaml:"id"`
+	}
+	for _, body := range []string{"id: [1", "id: abc"} {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set(ship.HeaderContentType, MIMEApplicationYAML)
+		ctx := s.AcquireContext(req, httptest.NewRecorder())
+		err := ctx.Bind(&v)
+		if assert.IsType(t, ship.BindError{}, err, body) {
+			assert.Equal(t, "yaml", err.(ship.BindError).Source)
+		}
+		s.ReleaseContext(ctx)
+	}
+}
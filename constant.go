@@ -33,6 +33,10 @@ const (
 	MIMEApplicationForm                  = "application/x-www-form-urlencoded"
 	MIMEApplicationProtobuf              = "application/protobuf"
 	MIMEApplicationMsgpack               = "application/msgpack"
+	MIMEApplicationYAML                  = "application/yaml"
+	MIMEApplicationCBOR                  = "application/cbor"
+	MIMEApplicationProblemJSON           = "application/problem+json"
+	MIMEApplicationProblemXML            = "application/problem+xml"
 	MIMETextHTML                         = "text/html"
 	MIMETextHTMLCharsetUTF8              = MIMETextHTML + "; " + CharsetUTF8
 	MIMETextPlain                        = "text/plain"
@@ -54,6 +58,10 @@ var (
 	MIMEApplicationForms                  = []string{MIMEApplicationForm}
 	MIMEApplicationProtobufs              = []string{MIMEApplicationProtobuf}
 	MIMEApplicationMsgpacks               = []string{MIMEApplicationMsgpack}
+	MIMEApplicationYAMLs                  = []string{MIMEApplicationYAML}
+	MIMEApplicationCBORs                  = []string{MIMEApplicationCBOR}
+	MIMEApplicationProblemJSONs           = []string{MIMEApplicationProblemJSON}
+	MIMEApplicationProblemXMLs            = []string{MIMEApplicationProblemXML}
 	MIMETextHTMLs                         = []string{MIMETextHTML}
 	MIMETextHTMLCharsetUTF8s              = []string{MIMETextHTMLCharsetUTF8}
 	MIMETextPlains                        = []string{MIMETextPlain}
@@ -33,6 +33,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/xgfone/ship/utils"
 )
@@ -129,13 +130,21 @@ func GetContext(req *http.Request) *Context {
 	return nil
 }
 
+// responder wraps the http.ResponseWriter to record that the response
+// has been sent.
+//
+// Only the responder wrapping the original http.ResponseWriter counts
+// the status, the size and the time of the first byte of the response,
+// so that the bytes written through the wrappers of the middlewares,
+// such as Gzip, are not counted more than once.
 type responder struct {
-	resp http.ResponseWriter
-	ctx  *Context
+	resp  http.ResponseWriter
+	ctx   *Context
+	count bool
 }
 
 func newResponder(ctx *Context, resp http.ResponseWriter) responder {
-	return responder{ctx: ctx, resp: resp}
+	return responder{ctx: ctx, resp: resp, count: true}
 }
 
 func (r *responder) reset(resp http.ResponseWriter) {
@@ -146,26 +155,59 @@ func (r responder) Header() http.Header {
 	return r.resp.Header()
 }
 
-func (r responder) Write(p []byte) (int, error) {
-	if !r.ctx.wrote {
-		r.ctx.wrote = true
+// writeHeader records the status code and the time of the first byte.
+func (r responder) writeHeader(code int) {
+	if r.count && r.ctx.status == 0 &&
+		(code >= 200 || code == http.StatusSwitchingProtocols) {
+		r.ctx.status = code
+		r.ctx.firstByteTime = time.Now()
 	}
-	return r.resp.Write(p)
+	r.ctx.wrote = true
+}
+
+func (r responder) Write(p []byte) (int, error) {
+	r.writeHeader(http.StatusOK)
+	n, err := r.resp.Write(p)
+	r.addSize(int64(n))
+	return n, err
 }
 
 func (r responder) WriteString(s string) (int, error) {
-	return io.WriteString(r.resp, s)
+	r.writeHeader(http.StatusOK)
+	n, err := io.WriteString(r.resp, s)
+	r.addSize(int64(n))
+	return n, err
 }
 
 // WriteHeader implements http.ResponseWriter#WriteHeader().
 func (r responder) WriteHeader(code int) {
 	r.resp.WriteHeader(code)
-	r.ctx.wrote = true
+	r.writeHeader(code)
+}
+
+// ReadFrom implements io.ReaderFrom, which will use the method ReadFrom
+// of the underlying http.ResponseWriter if it has, such as sendfile.
+func (r responder) ReadFrom(src io.Reader) (n int64, err error) {
+	r.writeHeader(http.StatusOK)
+	if rf, ok := r.resp.(io.ReaderFrom); ok {
+		n, err = rf.ReadFrom(src)
+	} else {
+		n, err = io.Copy(struct{ io.Writer }{r.resp}, src)
+	}
+	r.addSize(n)
+	return
+}
+
+func (r responder) addSize(n int64) {
+	if r.count {
+		r.ctx.size += n
+	}
 }
 
 // See [http.Flusher](https://golang.org/pkg/net/http/#Flusher)
 func (r responder) Flush() {
 	r.resp.(http.Flusher).Flush()
+	r.writeHeader(http.StatusOK)
 }
 
 // See [http.Hijacker](https://golang.org/pkg/net/http/#Hijacker)
@@ -173,6 +215,17 @@ func (r responder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
 	return r.resp.(http.Hijacker).Hijack()
 }
 
+// Push implements http.Pusher, which returns http.ErrNotSupported
+// if the underlying http.ResponseWriter does not support HTTP/2 Server Push.
+//
+// See [http.Pusher](https://golang.org/pkg/net/http/#Pusher)
+func (r responder) Push(target string, opts *http.PushOptions) error {
+	if p, ok := r.resp.(http.Pusher); ok {
+		return p.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
 // See [http.CloseNotifier](https://golang.org/pkg/net/http/#CloseNotifier)
 func (r responder) CloseNotify() <-chan bool {
 	return r.resp.(http.CloseNotifier).CloseNotify()
@@ -208,6 +261,10 @@ type Context struct {
 	ship  *Ship
 	wrote bool
 
+	status        int
+	size          int64
+	firstByteTime time.Time
+
 	req    *http.Request
 	resp   responder
 	query  url.Values
@@ -216,9 +273,20 @@ type Context struct {
 	handler func(*Context, ...interface{}) error
 	pnames  []string
 	pvalues []string
+	hnames  []string
+	hvalues []string
 
 	sessionK string
 	sessionV interface{}
+
+	routeName string
+	routePath string
+	logFields []interface{}
+	logger    Logger // The cached logger with the fields of the request
+	loggerRID string // The request id when caching the logger
+
+	// Only for the debug mode.
+	middlewares []string
 }
 
 // NewContext returns a new context.
@@ -256,6 +324,9 @@ func (c *Context) reset() {
 	}
 
 	c.wrote = false
+	c.status = 0
+	c.size = 0
+	c.firstByteTime = time.Time{}
 
 	c.req = nil
 	c.resp.reset(nil)
@@ -264,9 +335,18 @@ func (c *Context) reset() {
 
 	c.handler = nil
 	c.resetURLParam()
+	c.hnames = nil
+	c.hvalues = nil
 
 	c.sessionK = ""
 	c.sessionV = nil
+
+	c.routeName = ""
+	c.routePath = ""
+	c.logFields = nil
+	c.logger = nil
+	c.loggerRID = ""
+	c.middlewares = c.middlewares[:0]
 }
 
 func (c *Context) resetURLParam() {
@@ -316,14 +396,66 @@ func (c *Context) NotFoundHandler() Handler {
 	return c.ship.notFoundHandler
 }
 
+// HandleError handles the error by the error handler set by SetErrorHandler,
+// which is called automatically for the error returned by the handler.
+// So the middleware may use it to send the error response in advance,
+// then should not return the error any more.
+func (c *Context) HandleError(err error) {
+	c.ship.handleError(c, err)
+}
+
 // URL generates an URL by route name and provided parameters.
 func (c *Context) URL(name string, params ...interface{}) string {
+	if c.router != nil {
+		return c.router.URL(name, params...)
+	}
 	return c.ship.URL(name, params...)
 }
 
-// Logger returns the logger implementation.
+// Logger returns the logger of the current request.
+//
+// If the logger of the ship is FieldLogger, it is a child logger with
+// the fields about the request, that's, "method", "path", "route" if matching
+// a named route, "request_id" if the request has the header X-Request-ID,
+// and the fields added by AddLogFields. Or return the logger of the ship.
+//
+// The child logger is cached until the fields change.
 func (c *Context) Logger() Logger {
-	return c.ship.logger
+	logger, ok := c.ship.logger.(FieldLogger)
+	if !ok {
+		return c.ship.logger
+	}
+
+	xid := c.req.Header.Get(HeaderXRequestID)
+	if c.logger != nil && c.loggerRID == xid {
+		return c.logger
+	}
+
+	fields := make([]interface{}, 0, 8+len(c.logFields))
+	fields = append(fields, "method", c.req.Method, "path", c.req.URL.Path)
+	if c.routeName != "" {
+		fields = append(fields, "route", c.routeName)
+	}
+	if xid != "" {
+		fields = append(fields, "request_id", xid)
+	}
+	fields = append(fields, c.logFields...)
+	c.logger, c.loggerRID = logger.With(fields...), xid
+	return c.logger
+}
+
+// AddLogFields adds the key-value pairs, such as "key1", value1, ...,
+// into the logger of the current request, which will be output
+// with each log by Logger().
+//
+// It is used by the middleware to enrich the logger, for example,
+// with the authenticated user.
+func (c *Context) AddLogFields(keysAndValues ...interface{}) {
+	c.logFields = append(c.logFields, keysAndValues...)
+	if len(keysAndValues)%2 == 1 {
+		c.logFields = append(c.logFields, nil)
+	}
+	c.logger = nil
 }
 
 // Router returns the router.
@@ -403,9 +535,14 @@ func (c *Context) Request() *http.Request {
 	return c.req
 }
 
-// Response returns the inner http.ResponseWriter.
+// Response returns the inner http.ResponseWriter, which is the wrapper
+// of the original http.ResponseWriter or the one set by SetResponse,
+// and records that the response has been sent.
+//
+// Only the bytes written into the original http.ResponseWriter are counted
+// as the status, the size and the time of the first byte of the response.
 func (c *Context) Response() http.ResponseWriter {
-	return newResponder(c, c.resp.resp)
+	return c.resp
 }
 
 // IsResponded reports whether the response is sent.
@@ -419,17 +556,69 @@ func (c *Context) SetResponded(yes bool) {
 }
 
 // SetResponse resets the response to resp, which will ignore nil.
+//
+// resp should write the response through the one returned by Response,
+// which records the status, the size and the time of the first byte,
+// for example, the wrapper to compress the response body.
 func (c *Context) SetResponse(resp http.ResponseWriter) {
-	if resp != nil {
-		c.resp.reset(resp)
+	if resp == nil {
+		return
+	} else if r, ok := resp.(responder); ok && r.ctx == c {
+		c.resp = r
+	} else {
+		c.resp = responder{ctx: c, resp: resp}
 	}
 }
 
+// StatusCode returns the status code of the response which has been sent.
+//
+// Return 0 if the response has not been sent.
+func (c *Context) StatusCode() int {
+	return c.status
+}
+
+// ResponseSize returns the number of the bytes of the response body
+// which have been sent, that's, written into the original
+// http.ResponseWriter. For example, it is the compressed size
+// if the response body is compressed by the middleware Gzip.
+func (c *Context) ResponseSize() int64 {
+	return c.size
+}
+
+// FirstByteTime returns the time when the response header is written,
+// that's, the first byte of the response is sent.
+//
+// Return the zero time if the response has not been sent.
+func (c *Context) FirstByteTime() time.Time {
+	return c.firstByteTime
+}
+
+// IsHeaderWritten reports whether the response header has been written
+// to the client, after which the header and the status code cannot be
+// changed any more.
+func (c *Context) IsHeaderWritten() bool {
+	return c.status != 0
+}
+
 // SetConnectionClose tell the server to close the connection.
 func (c *Context) SetConnectionClose() {
 	c.resp.Header().Set(HeaderConnection, "close")
 }
 
+// RouteName returns the name of the matched route.
+//
+// Return "" if no route matches the request or the route has no name.
+func (c *Context) RouteName() string {
+	return c.routeName
+}
+
+// RoutePath returns the path of the matched route, such as "/users/:id".
+//
+// Return "" if no route matches the request.
+func (c *Context) RoutePath() string {
+	return c.routePath
+}
+
 // Param returns the parameter value in the url path by name.
 func (c *Context) Param(name string) string {
 	for i, _len := 0, len(c.pnames); i < _len; i++ {
@@ -484,6 +673,29 @@ func (c *Context) ParamValues() []string {
 	return c.pvalues
 }
 
+// HostParam returns the value of the capture named name in the virtual host
+// pattern, such as "{tenant}.example.com".
+//
+// Return "" if no the capture.
+func (c *Context) HostParam(name string) string {
+	for i, n := range c.hnames {
+		if n == name {
+			return c.hvalues[i]
+		}
+	}
+	return ""
+}
+
+// HostParams returns all the captures of the virtual host pattern
+// as the key-value map.
+func (c *Context) HostParams() map[string]string {
+	ms := make(map[string]string, len(c.hnames))
+	for i, n := range c.hnames {
+		ms[n] = c.hvalues[i]
+	}
+	return ms
+}
+
 // ParamToStruct scans the url parameters to a pointer v to the struct.
 //
 // For the struct, the argument name is the field name by default. But you can
@@ -525,7 +737,7 @@ func (c *Context) ParamToStruct(v interface{}) error {
 
 		if v := c.Param(name); v != "" {
 			if err := utils.SetValue(fieldv.Interface(), v); err != nil {
-				return err
+				return BindError{Field: fieldt.Name, Source: "path", Value: v, Err: err}
 			}
 		}
 	}
@@ -556,10 +768,28 @@ func (c *Context) IsDebug() bool {
 }
 
 // IsTLS reports whether HTTP connection is TLS or not.
+//
+// Notice: it is false for the cleartext HTTP/2 (h2c), even if the client
+// sends the pseudo-header ":scheme" with "https".
 func (c *Context) IsTLS() bool {
 	return c.req.TLS != nil
 }
 
+// Protocol returns the negotiated protocol of the connection, such as
+// "http/1.0", "http/1.1", "h2" for HTTP/2 over TLS, or "h2c" for
+// the cleartext HTTP/2.
+func (c *Context) Protocol() string {
+	if c.req.TLS != nil && c.req.TLS.NegotiatedProtocol != "" {
+		return c.req.TLS.NegotiatedProtocol
+	} else if c.req.ProtoMajor == 2 {
+		if c.req.TLS != nil {
+			return "h2"
+		}
+		return "h2c"
+	}
+	return strings.ToLower(c.req.Proto)
+}
+
 // IsWebSocket reports whether HTTP connection is WebSocket or not.
 func (c *Context) IsWebSocket() bool {
 	if c.req.Method == http.MethodGet &&
@@ -650,6 +880,9 @@ func (c *Context) Method() string {
 }
 
 // Scheme returns the HTTP protocol scheme, `http` or `https`.
+//
+// For the cleartext HTTP/2 (h2c), it is `http` unless the proxy forwards
+// the original scheme by the headers such as X-Forwarded-Proto.
 func (c *Context) Scheme() (scheme string) {
 	// Can't use `r.Request.URL.Scheme`
 	// See: https://groups.google.com/forum/#!topic/golang-nuts/pMUkBlQBDF0
@@ -916,16 +1149,118 @@ func (c *Context) DelSession(id string) (err error) {
 	return nil
 }
 
-// Bind binds the request information into the provided value v.
+// Bind binds the request information into the provided value v,
+// then validates it if the Validator is set.
 //
 // The default binder does it based on Content-Type header.
 func (c *Context) Bind(v interface{}) error {
-	return c.ship.binder.Bind(c, v)
+	if err := c.ship.binder.Bind(c, v); err != nil {
+		return err
+	}
+	return c.Validate(v)
 }
 
-// BindQuery binds the request URL query into the provided value v.
+// BindQuery binds the request URL query into the provided value v,
+// then validates it if the Validator is set.
 func (c *Context) BindQuery(v interface{}) error {
-	return c.ship.bindQuery(v, c.QueryParams())
+	var err error
+	if c.ship.bindQuery == nil {
+		err = c.bindURLValues(v, c.QueryParams(), "query", false)
+	} else {
+		err = c.ship.bindQuery(v, c.QueryParams())
+	}
+
+	if err != nil {
+		return err
+	}
+	return c.Validate(v)
+}
+
+// BindRequest binds the request information from the body, the cookie,
+// the header, the query and the URL path parameter into v, which must be
+// a pointer to struct, then validates it if the Validator is set.
+//
+// The fields declare their source by the tag as follow:
+//
+//     type Request struct {
+//         ID     int    `path:"id"`
+//         Page   int    `query:"page"`
+//         Tenant string `header:"X-Tenant"`
+//         SID    string `cookie:"sid"`
+//         Name   string `json:"name"` // From the body
+//     }
+//
+// The body is bound firstly by the Binder if it has the body and
+// the header Content-Type, then the cookie, the header, the query and the
+// URL path parameter in turn. So the latter takes precedence over
+// the former for the same field.
+//
+// Notice: for the cookie, the header, the query and the URL path parameter,
+// only the fields with the corresponding tag are bound.
+func (c *Context) BindRequest(v interface{}) (err error) {
+	if c.req.Body != nil && c.req.Body != http.NoBody && c.ContentType() != "" {
+		if err = c.ship.binder.Bind(c, v); err != nil {
+			return
+		}
+	}
+
+	if cookies := c.req.Cookies(); len(cookies) > 0 {
+		values := make(url.Values, len(cookies))
+		for _, cookie := range cookies {
+			values.Add(cookie.Name, cookie.Value)
+		}
+		if err = c.bindURLValues(v, values, "cookie", true); err != nil {
+			return
+		}
+	}
+
+	if err = c.bindURLValues(v, url.Values(c.req.Header), "header", true); err != nil {
+		return
+	}
+
+	if err = c.bindURLValues(v, c.QueryParams(), "query", true); err != nil {
+		return
+	}
+
+	if names := c.ParamNames(); len(names) > 0 {
+		values := make(url.Values, len(names))
+		for i, name := range names {
+			values[name] = []string{c.pvalues[i]}
+		}
+		if err = c.bindURLValues(v, values, "path", true); err != nil {
+			return
+		}
+	}
+
+	return c.Validate(v)
+}
+
+// bindURLValues binds the values to v with the bind limits of the ship.
+func (c *Context) bindURLValues(v interface{}, data url.Values, tag string,
+	onlyTag bool) error {
+	return bindURLValues(v, data, tag, onlyTag, c.ship.bindLimits)
+}
+
+// Validate validates whether v is valid by the Validator.
+//
+// If the Validator is not set, it does nothing and returns nil.
+// If v is invalid, it returns ErrBadRequest with the error returned by
+// the Validator, such as ValidationErrors, as the inner error.
+func (c *Context) Validate(v interface{}) error {
+	if c.ship.validator == nil {
+		return nil
+	}
+
+	switch err := c.ship.validator.Validate(v).(type) {
+	case nil:
+		return nil
+	case HTTPError:
+		return err
+	case ValidationErrors, FieldError:
+		return ErrBadRequest.NewError(err)
+	default:
+		return err
+	}
 }
 
 // Render renders a template named name with data and sends a text/html response
@@ -937,6 +1272,28 @@ func (c *Context) Render(name string, code int, data interface{}) error {
 	return c.ship.renderer.Render(c, name, code, data)
 }
 
+// Negotiate renders data with status code by the best media type,
+// which is negotiated by the request header Accept and the negotiation table
+// of MuxRenderer. See MuxRenderer.AddMediaType.
+//
+// It will add the response header "Vary: Accept". If no media type is
+// acceptable, it returns ErrNotAcceptable with the available media types.
+//
+// Notice: the renderer of Ship must be MuxRenderer.
+func (c *Context) Negotiate(code int, data interface{}) error {
+	mr, ok := c.ship.renderer.(*MuxRenderer)
+	if !ok {
+		return ErrRendererNotRegistered
+	}
+
+	c.resp.Header().Add(HeaderVary, HeaderAccept)
+	if mediaType, name := mr.Negotiate(c.Accept()); mediaType != "" {
+		return mr.Render(c, name, code, data)
+	}
+	return ErrNotAcceptable.NewMsg("acceptable media types: %s",
+		strings.Join(mr.MediaTypes(), ", "))
+}
+
 // Write writes the content to the peer.
 //
 // it will write the header firstly if the header is not sent.
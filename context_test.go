@@ -16,9 +16,12 @@ package ship
 
 import (
 	"bytes"
+	"crypto/tls"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -166,7 +169,7 @@ func TestContextURLParam(t *testing.T) {
 		maps = c.Params()
 		return nil
 	})
-	maxParam = s.maxNum
+	maxParam = s.getURLParamNum()
 
 	req := httptest.NewRequest(http.MethodGet, "/hello/aaron/123", nil)
 	rec := httptest.NewRecorder()
@@ -205,3 +208,135 @@ func TestContext_ParamToStruct(t *testing.T) {
 	assert.Equal(t, 0, v.Age)
 	assert.Equal(t, "", v.Address)
 }
+
+func TestContextLogger(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	s := New(SetLogger(NewLevelLogger(buf, LvlInfo, TextLogEncoder("-"))))
+	s.Use(func(next Handler) Handler {
+		return func(ctx *Context) error {
+			ctx.AddLogFields("user", "aaron", "odd")
+			return next(ctx)
+		}
+	})
+	s.R("/users/:id").Name("get_user").GET(func(ctx *Context) error {
+		ctx.Logger().Info("get user")
+		return nil
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/123", nil)
+	req.Header.Set(HeaderXRequestID, "abc")
+	s.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, "time=- level=INFO msg=\"get user\" method=GET path=/users/123 "+
+		"route=get_user request_id=abc user=aaron odd=\"\"\n", buf.String())
+
+	// The logger is cached until the fields change.
+	ctx := s.AcquireContext(httptest.NewRequest(http.MethodGet, "/", nil), nil)
+	logger1 := ctx.Logger()
+	assert.True(t, logger1 == ctx.Logger())
+	fields := []interface{}{"key", "value"}
+	ctx.AddLogFields(fields[:1]...)
+	assert.Equal(t, []interface{}{"key", "value"}, fields) // Not modified
+	logger2 := ctx.Logger()
+	assert.False(t, logger1 == logger2)
+	ctx.Request().Header.Set(HeaderXRequestID, "xyz")
+	assert.False(t, logger2 == ctx.Logger())
+
+	// The fields are reset when the context is released.
+	ctx.AddLogFields("user", "aaron")
+	s.ReleaseContext(ctx)
+	ctx = s.AcquireContext(httptest.NewRequest(http.MethodGet, "/", nil), nil)
+	assert.Len(t, ctx.logFields, 0)
+	assert.Nil(t, ctx.logger)
+	s.ReleaseContext(ctx)
+
+	// The logger without the fields.
+	logger := NewNoLevelLogger(buf)
+	s = New(SetLogger(logger))
+	ctx = s.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), nil)
+	assert.Equal(t, logger, ctx.Logger())
+}
+
+func TestContextResponseStatus(t *testing.T) {
+	s := New()
+	rec := httptest.NewRecorder()
+	ctx := s.AcquireContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+	assert.Equal(t, 0, ctx.StatusCode())
+	assert.False(t, ctx.IsHeaderWritten())
+	assert.True(t, ctx.FirstByteTime().IsZero())
+
+	resp := ctx.Response()
+	resp.WriteHeader(http.StatusCreated)
+	resp.WriteHeader(http.StatusAccepted)
+	resp.Write([]byte("abc"))
+	resp.(interface {
+		WriteString(string) (int, error)
+	}).WriteString("def")
+	resp.(io.ReaderFrom).ReadFrom(strings.NewReader("ghi"))
+	assert.Equal(t, http.StatusCreated, ctx.StatusCode())
+	assert.Equal(t, int64(9), ctx.ResponseSize())
+	assert.True(t, ctx.IsHeaderWritten())
+	assert.False(t, ctx.FirstByteTime().IsZero())
+	assert.Equal(t, http.ErrNotSupported, resp.(http.Pusher).Push("/static/app.js", nil))
+	assert.Equal(t, "abcdefghi", rec.Body.String())
+	s.ReleaseContext(ctx)
+
+	ctx = s.AcquireContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+	assert.Equal(t, 0, ctx.StatusCode())
+	assert.Equal(t, int64(0), ctx.ResponseSize())
+	ctx.String(http.StatusOK, "hello")
+	assert.Equal(t, http.StatusOK, ctx.StatusCode())
+	assert.Equal(t, int64(5), ctx.ResponseSize())
+	s.ReleaseContext(ctx)
+
+	// Only count the bytes written into the original response.
+	rec = httptest.NewRecorder()
+	ctx = s.AcquireContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+	resp = ctx.Response()
+	buf := new(bytes.Buffer)
+	ctx.SetResponse(&testResponse{ResponseWriter: resp, buf: buf})
+	ctx.String(http.StatusOK, "hello")
+	assert.Equal(t, int64(0), ctx.ResponseSize())
+	assert.True(t, ctx.IsResponded())
+	ctx.SetResponse(resp)
+	resp.Write([]byte("abc"))
+	assert.Equal(t, int64(3), ctx.ResponseSize())
+	assert.Equal(t, "abc", rec.Body.String())
+	assert.Equal(t, "hello", buf.String())
+	s.ReleaseContext(ctx)
+
+	// The response which does not wrap the original is also recorded as sent.
+	ctx = s.AcquireContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+	ctx.SetResponse(httptest.NewRecorder())
+	ctx.Response().Write([]byte("abc"))
+	assert.True(t, ctx.IsResponded())
+	assert.Equal(t, int64(0), ctx.ResponseSize())
+	s.ReleaseContext(ctx)
+}
+
+// testResponse buffers the response body.
+type testResponse struct {
+	http.ResponseWriter
+	buf *bytes.Buffer
+}
+
+func (r *testResponse) Write(p []byte) (int, error) { return r.buf.Write(p) }
+
+func TestContextProtocol(t *testing.T) {
+	s := New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	ctx := s.AcquireContext(req, httptest.NewRecorder())
+	assert.Equal(t, "http/1.1", ctx.Protocol())
+	assert.Equal(t, "http", ctx.Scheme())
+
+	req.Proto, req.ProtoMajor, req.ProtoMinor = "HTTP/2.0", 2, 0
+	assert.Equal(t, "h2c", ctx.Protocol())
+	assert.False(t, ctx.IsTLS())
+	assert.Equal(t, "http", ctx.Scheme())
+
+	req.TLS = &tls.ConnectionState{}
+	assert.Equal(t, "h2", ctx.Protocol())
+	assert.Equal(t, "https", ctx.Scheme())
+	req.TLS.NegotiatedProtocol = "h2"
+	assert.Equal(t, "h2", ctx.Protocol())
+	s.ReleaseContext(ctx)
+}
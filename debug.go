@@ -0,0 +1,178 @@
+// Copyright 2019 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ship
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// debugMiddleware is the same as m(next), but records the name of
+// the middleware into the context when it runs.
+func debugMiddleware(m Middleware, next Handler) Handler {
+	name := runtime.FuncForPC(reflect.ValueOf(m).Pointer()).Name()
+	handler := m(next)
+	return func(ctx *Context) error {
+		ctx.middlewares = append(ctx.middlewares, name)
+		return handler(ctx)
+	}
+}
+
+// DebugInfo is the information of the failed request in the debug mode.
+//
+// The values of the headers Authorization, Cookie and Proxy-Authorization
+// are redacted as "***".
+type DebugInfo struct {
+	Status      int               `json:"status"`
+	Error       string            `json:"error"`
+	Stack       string            `json:"stack,omitempty"`
+	Method      string            `json:"method"`
+	URL         string            `json:"url"`
+	RouteName   string            `json:"route_name,omitempty"`
+	RoutePath   string            `json:"route_path,omitempty"`
+	Params      map[string]string `json:"params,omitempty"`
+	Headers     http.Header       `json:"headers,omitempty"`
+	Data        map[string]string `json:"data,omitempty"`
+	Middlewares []string          `json:"middlewares,omitempty"`
+}
+
+func newDebugInfo(ctx *Context, err error) DebugInfo {
+	info := DebugInfo{
+		Status:      ErrorStatusCode(err),
+		Error:       err.Error(),
+		Method:      ctx.req.Method,
+		URL:         ctx.req.URL.String(),
+		RouteName:   ctx.routeName,
+		RoutePath:   ctx.routePath,
+		Params:      ctx.Params(),
+		Headers:     redactDebugHeaders(ctx.req.Header),
+		Middlewares: ctx.middlewares,
+	}
+
+	if pe, ok := AsPanicError(err); ok {
+		info.Stack = pe.Stack
+	}
+
+	if len(ctx.Data) > 0 {
+		info.Data = make(map[string]string, len(ctx.Data))
+		for key, value := range ctx.Data {
+			info.Data[key] = fmt.Sprintf("%+v", value)
+		}
+	}
+
+	return info
+}
+
+// debugRedactedHeaders are the request headers carrying the credentials,
+// the values of which are not shown in the debug page.
+var debugRedactedHeaders = []string{
+	HeaderAuthorization,
+	HeaderCookie,
+	"Proxy-Authorization",
+}
+
+// redactDebugHeaders returns a copy of the headers with the values
+// of the credential headers replaced by "***".
+func redactDebugHeaders(header http.Header) http.Header {
+	header = cloneHeader(header)
+	for _, key := range debugRedactedHeaders {
+		if values := header[key]; len(values) > 0 {
+			redacted := make([]string, len(values))
+			for i := range redacted {
+				redacted[i] = "***"
+			}
+			header[key] = redacted
+		}
+	}
+	return header
+}
+
+func cloneHeader(header http.Header) http.Header {
+	h := make(http.Header, len(header))
+	for key, values := range header {
+		h[key] = append([]string(nil), values...)
+	}
+	return h
+}
+
+// handleErrorDebug responds the developer error page, which is HTML
+// if the request accepts "text/html", or JSON.
+func handleErrorDebug(ctx *Context, err error) {
+	info := newDebugInfo(ctx, err)
+	for _, accept := range ctx.Accept() {
+		if accept == MIMETextHTML {
+			buf := bytes.NewBuffer(nil)
+			if debugTemplate.Execute(buf, info) == nil {
+				ctx.Blob(info.Status, MIMETextHTMLCharsetUTF8, buf.Bytes())
+				return
+			}
+			break
+		} else if accept == MIMEApplicationJSON || accept == "" {
+			break
+		}
+	}
+
+	b, _ := json.MarshalIndent(info, "", "    ")
+	ctx.Blob(info.Status, MIMEApplicationJSONCharsetUTF8, b)
+}
+
+var debugTemplate = template.Must(template.New("debug").Funcs(template.FuncMap{
+	"join": strings.Join,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Status}} {{.Error}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+pre { background: #f6f8fa; padding: 1em; overflow: auto; }
+th { text-align: left; padding-right: 2em; vertical-align: top; }
+</style>
+</head>
+<body>
+<h1>{{.Status}} {{.Error}}</h1>
+<p>{{.Method}} {{.URL}}</p>
+{{if .Stack}}<h2>Stack</h2>
+<pre>{{.Stack}}</pre>{{end}}
+<h2>Route</h2>
+<table>
+<tr><th>Name</th><td>{{.RouteName}}</td></tr>
+<tr><th>Path</th><td>{{.RoutePath}}</td></tr>
+</table>
+{{with .Params}}<h2>URL Parameters</h2>
+<table>{{range $k, $v := .}}
+<tr><th>{{$k}}</th><td>{{$v}}</td></tr>{{end}}
+</table>{{end}}
+{{with .Middlewares}}<h2>Middlewares</h2>
+<ol>{{range .}}
+<li>{{.}}</li>{{end}}
+</ol>{{end}}
+{{with .Headers}}<h2>Headers</h2>
+<table>{{range $k, $v := .}}
+<tr><th>{{$k}}</th><td>{{join $v ", "}}</td></tr>{{end}}
+</table>{{end}}
+{{with .Data}}<h2>Data</h2>
+<table>{{range $k, $v := .}}
+<tr><th>{{$k}}</th><td>{{$v}}</td></tr>{{end}}
+</table>{{end}}
+</body>
+</html>
+`))
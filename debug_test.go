@@ -0,0 +1,88 @@
+// Copyright 2019 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ship
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDebugErrorPage(t *testing.T) {
+	stack := "goroutine 1 [running]:\nmain.main()"
+	mw := func(next Handler) Handler {
+		return func(ctx *Context) error {
+			ctx.Data["user"] = "aaron"
+			return next(ctx)
+		}
+	}
+
+	s := New(SetDebug(true), DisableErrorLog(true))
+	s.Use(mw)
+	s.R("/users/:id").Name("get_user").GET(func(ctx *Context) error {
+		return PanicError{Value: "boom", Stack: stack}
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/123", nil)
+	req.Header.Set("X-Test", "abc")
+	req.Header.Set(HeaderAuthorization, "Bearer secret")
+	req.Header.Set("Proxy-Authorization", "Basic secret")
+	req.AddCookie(&http.Cookie{Name: "sid", Value: "secret"})
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	var info DebugInfo
+	if assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &info)) {
+		assert.Equal(t, "boom", info.Error)
+		assert.Equal(t, stack, info.Stack)
+		assert.Equal(t, "get_user", info.RouteName)
+		assert.Equal(t, "/users/:id", info.RoutePath)
+		assert.Equal(t, map[string]string{"id": "123"}, info.Params)
+		assert.Equal(t, map[string]string{"user": "aaron"}, info.Data)
+		assert.Equal(t, "abc", info.Headers.Get("X-Test"))
+		assert.Equal(t, "***", info.Headers.Get(HeaderAuthorization))
+		assert.Equal(t, "***", info.Headers.Get(HeaderCookie))
+		assert.Equal(t, "***", info.Headers.Get("Proxy-Authorization"))
+		assert.NotContains(t, rec.Body.String(), "secret")
+		assert.Equal(t, "Bearer secret", req.Header.Get(HeaderAuthorization))
+		if assert.Len(t, info.Middlewares, 1) {
+			assert.Contains(t, info.Middlewares[0], "TestDebugErrorPage")
+		}
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/users/123", nil)
+	req.Header.Set(HeaderAccept, "text/html,application/xhtml+xml")
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, MIMETextHTMLCharsetUTF8, rec.Header().Get(HeaderContentType))
+	assert.True(t, strings.Contains(rec.Body.String(), "main.main()"))
+	assert.True(t, strings.Contains(rec.Body.String(), "get_user"))
+
+	// The production mode
+	s = New(DisableErrorLog(true))
+	s.R("/users/:id").Name("get_user").GET(func(ctx *Context) error {
+		return PanicError{Value: "boom", Stack: stack}
+	})
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/123", nil))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, "", rec.Body.String())
+}
@@ -36,6 +36,7 @@ var (
 var (
 	ErrUnsupportedMediaType        = NewHTTPError(http.StatusUnsupportedMediaType)
 	ErrNotFound                    = NewHTTPError(http.StatusNotFound)
+	ErrNotAcceptable               = NewHTTPError(http.StatusNotAcceptable)
 	ErrUnauthorized                = NewHTTPError(http.StatusUnauthorized)
 	ErrForbidden                   = NewHTTPError(http.StatusForbidden)
 	ErrMethodNotAllowed            = NewHTTPError(http.StatusMethodNotAllowed)
@@ -60,6 +61,26 @@ type HTTPError struct {
 	Msg  string
 	Err  error
 	CT   string // For Content-Type
+
+	// ErrCode is the stable and machine-readable error code,
+	// such as "user_not_found".
+	ErrCode string
+
+	// Type is the URI reference that identifies the problem type,
+	// which is used by ProblemErrorHandler.
+	Type string
+
+	fields *httpErrorFields
+}
+
+type httpErrorField struct {
+	key   string
+	value interface{}
+}
+
+// httpErrorFields is a pointer in HTTPError to keep HTTPError comparable.
+type httpErrorFields struct {
+	fields []httpErrorField
 }
 
 // NewHTTPError returns a new HTTPError.
@@ -74,7 +95,51 @@ func (e HTTPError) Error() string {
 	if e.Err != nil {
 		return e.Err.Error()
 	}
-	return fmt.Sprintf("code=%d, msg='%s'", e.Code, e.Err)
+	return fmt.Sprintf("code=%d, msg='%s'", e.Code, e.Msg)
+}
+
+// Unwrap returns the inner error.
+func (e HTTPError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether e matches target, which is used by errors.Is.
+//
+// If target is an HTTPError only with the status code, such as ErrNotFound,
+// it matches e with the same status code. So the code below is true.
+//
+//     errors.Is(ErrNotFound.NewMsg("the user does not exist"), ErrNotFound)
+//
+func (e HTTPError) Is(target error) bool {
+	t, ok := target.(HTTPError)
+	return ok && t.Code == e.Code && t.Msg == "" && t.Err == nil &&
+		t.ErrCode == "" && t.Type == "" && t.fields == nil
+}
+
+// BindError is the error returned by the binders when failing to bind
+// the request data to a value, which will be converted to ErrBadRequest
+// by the default error handler.
+type BindError struct {
+	// Field is the path of the failed field, such as "Address.City" or
+	// "Items[0].SKU", which may be empty if the error is not relevant to
+	// a certain field, such as the syntax error of JSON.
+	Field string `json:"field" xml:"field"`
+
+	Source string `json:"source" xml:"source"`                   // The source of the data, such as "json", "form", "query", etc.
+	Value  string `json:"value,omitempty" xml:"value,omitempty"` // The offending value, which may be empty.
+	Err    error  `json:"-" xml:"-"`                             // The underlying error.
+}
+
+func (e BindError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("cannot bind the %s: %s", e.Source, e.Err)
+	}
+	return fmt.Sprintf("cannot bind the field '%s' from the %s: %s", e.Field, e.Source, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e BindError) Unwrap() error {
+	return e.Err
 }
 
 // NewError returns a new HTTPError with the new error.
@@ -84,6 +149,55 @@ func (e HTTPError) NewError(err error) HTTPError {
 	return nerr
 }
 
+// NewErrCode returns a new HTTPError with the new stable error code.
+func (e HTTPError) NewErrCode(code string) HTTPError {
+	nerr := e
+	nerr.ErrCode = code
+	return nerr
+}
+
+// NewType returns a new HTTPError with the new problem type.
+func (e HTTPError) NewType(_type string) HTTPError {
+	nerr := e
+	nerr.Type = _type
+	return nerr
+}
+
+// NewField returns a new HTTPError with the extension field,
+// which will override the old field with the same key.
+//
+// The extension fields are used as the extension members of the problem
+// details by ProblemErrorHandler, such as the validation errors.
+func (e HTTPError) NewField(key string, value interface{}) HTTPError {
+	var fields []httpErrorField
+	if e.fields != nil {
+		fields = make([]httpErrorField, 0, len(e.fields.fields)+1)
+		for _, f := range e.fields.fields {
+			if f.key != key {
+				fields = append(fields, f)
+			}
+		}
+	}
+
+	nerr := e
+	fields = append(fields, httpErrorField{key: key, value: value})
+	nerr.fields = &httpErrorFields{fields: fields}
+	return nerr
+}
+
+// Fields returns all the extension fields. Return nil if no fields.
+func (e HTTPError) Fields() map[string]interface{} {
+	if e.fields == nil {
+		return nil
+	}
+
+	fields := make(map[string]interface{}, len(e.fields.fields))
+	for _, f := range e.fields.fields {
+		fields[f.key] = f.value
+	}
+	return fields
+}
+
 // NewMsg returns a new HTTPError with the new msg.
 func (e HTTPError) NewMsg(msg string, args ...interface{}) HTTPError {
 	nerr := e
@@ -94,3 +208,109 @@ func (e HTTPError) NewMsg(msg string, args ...interface{}) HTTPError {
 	}
 	return nerr
 }
+
+// PanicError is the error converted from the panic, which carries
+// the value passed to panic and the stack.
+type PanicError struct {
+	Value interface{} // The value passed to panic.
+	Stack string      // The stack trace, such as the result of runtime/debug.Stack().
+}
+
+func (e PanicError) Error() string {
+	return fmt.Sprint(e.Value)
+}
+
+// Unwrap returns the panic value if it is an error, or nil.
+func (e PanicError) Unwrap() error {
+	if err, ok := e.Value.(error); ok {
+		return err
+	}
+	return nil
+}
+
+// unwrapError returns the error wrapped by err, or nil if err does not
+// have the method Unwrap.
+func unwrapError(err error) error {
+	if u, ok := err.(interface{ Unwrap() error }); ok {
+		return u.Unwrap()
+	}
+	return nil
+}
+
+// AsHTTPError finds the first HTTPError in the chain of err,
+// which is unwrapped by the method "Unwrap() error".
+func AsHTTPError(err error) (HTTPError, bool) {
+	for ; err != nil; err = unwrapError(err) {
+		if e, ok := err.(HTTPError); ok {
+			return e, true
+		}
+	}
+	return HTTPError{}, false
+}
+
+// AsPanicError finds the first PanicError in the chain of err,
+// which is unwrapped by the method "Unwrap() error".
+func AsPanicError(err error) (PanicError, bool) {
+	for ; err != nil; err = unwrapError(err) {
+		if e, ok := err.(PanicError); ok {
+			return e, true
+		}
+	}
+	return PanicError{}, false
+}
+
+// ErrorStatusCode classifies the error into the HTTP status code.
+//
+// It finds the first known error in the chain of err:
+//
+//     HTTPError                           ->  HTTPError.Code
+//     BindError, ValidationErrors         ->  400
+//     ErrCookieNotFound                   ->  400
+//     ErrMissingContentType               ->  400
+//     ErrTrailingData                     ->  400
+//     ErrSessionNotExist                  ->  401
+//     ErrInvalidSession                   ->  401
+//     ErrNoHandler                        ->  404
+//     ErrRendererNotRegistered            ->  500 with the message
+//     Others                              ->  500
+//
+// Notice: BindError wrapping an HTTPError uses the code of the HTTPError.
+func ErrorStatusCode(err error) int {
+	if e, ok := toHTTPError(err); ok {
+		return e.Code
+	}
+	return http.StatusInternalServerError
+}
+
+// toHTTPError converts the error to HTTPError by the chain of err.
+func toHTTPError(err error) (HTTPError, bool) {
+	for e := err; e != nil; e = unwrapError(e) {
+		var code int
+		switch v := e.(type) {
+		case HTTPError:
+			return v, true
+		case BindError:
+			if he, ok := v.Err.(HTTPError); ok {
+				return he, true
+			}
+			code = http.StatusBadRequest
+		case ValidationErrors, FieldError:
+			code = http.StatusBadRequest
+		default:
+			switch e {
+			case ErrCookieNotFound, ErrMissingContentType, ErrTrailingData:
+				code = http.StatusBadRequest
+			case ErrSessionNotExist, ErrInvalidSession:
+				code = http.StatusUnauthorized
+			case ErrNoHandler:
+				code = http.StatusNotFound
+			case ErrRendererNotRegistered:
+				return ErrInternalServerError.NewMsg(e.Error()).NewError(err), true
+			default:
+				continue
+			}
+		}
+		return NewHTTPError(code).NewError(err), true
+	}
+	return HTTPError{}, false
+}
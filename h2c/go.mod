@@ -0,0 +1,18 @@
+module github.com/xgfone/ship/h2c
+
+go 1.18
+
+require (
+	github.com/stretchr/testify v1.8.2
+	github.com/xgfone/ship v0.0.0
+	golang.org/x/net v0.19.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/xgfone/ship => ..
@@ -0,0 +1,147 @@
+// Copyright 2019 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package h2c supplies the cleartext HTTP/2 (h2c) and the HTTP/2 settings
+// for the ship server, which depends on "golang.org/x/net/http2".
+//
+// The h2c connection is supported by both the prior knowledge
+// (RFC 7540 Section 3.4) and the upgrade from HTTP/1.1 (Section 3.2).
+// For example,
+//
+//     router := ship.New(h2c.Enable(h2c.Config{MaxConcurrentStreams: 1000}))
+//     router.Start(":8080")
+//
+package h2c
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/xgfone/ship"
+	"golang.org/x/net/http2"
+	xh2c "golang.org/x/net/http2/h2c"
+)
+
+// Config is used to configure HTTP/2, the zero value of each field of which
+// means to use the default of "golang.org/x/net/http2".
+type Config struct {
+	// MaxConcurrentStreams is the number of the concurrent streams
+	// that each client may have open at a time.
+	MaxConcurrentStreams uint32
+
+	// MaxReadFrameSize is the largest frame this server is willing to read,
+	// which is between 16KB and 16MB.
+	MaxReadFrameSize uint32
+
+	// MaxUploadBufferPerConnection is the size of the initial flow control
+	// window for each connection, which must be at least 64KB.
+	MaxUploadBufferPerConnection int32
+
+	// MaxUploadBufferPerStream is the size of the initial flow control
+	// window for each stream.
+	MaxUploadBufferPerStream int32
+
+	// IdleTimeout is the timeout after which the idle connection is closed.
+	// If zero, use the IdleTimeout or ReadTimeout of http.Server.
+	IdleTimeout time.Duration
+
+	// DisableUpgrade disables the upgrade from HTTP/1.1 to h2c,
+	// and only the prior knowledge is supported.
+	DisableUpgrade bool
+
+	// DisableH2C disables the cleartext HTTP/2, and only the settings
+	// are applied to HTTP/2 over TLS.
+	DisableH2C bool
+}
+
+func (c Config) server() *http2.Server {
+	return &http2.Server{
+		MaxConcurrentStreams:         c.MaxConcurrentStreams,
+		MaxReadFrameSize:             c.MaxReadFrameSize,
+		MaxUploadBufferPerConnection: c.MaxUploadBufferPerConnection,
+		MaxUploadBufferPerStream:     c.MaxUploadBufferPerStream,
+		IdleTimeout:                  c.IdleTimeout,
+	}
+}
+
+// Enable returns a ship option to configure HTTP/2 for the server started
+// by the ship, which serves h2c and HTTP/2 over TLS with the config.
+//
+// If the config is missing, use the zero value.
+//
+// It is appended by ship.SetServerConfigurer, so it works together with
+// the other server configurers, which are called in turn by the order of
+// the options. So the configurers after it see the handler wrapped for h2c.
+func Enable(config ...Config) ship.Option {
+	var conf Config
+	if len(config) > 0 {
+		conf = config[0]
+	}
+	return ship.SetServerConfigurer(func(server *http.Server) error {
+		return ConfigureServer(server, conf)
+	})
+}
+
+// ConfigureServer configures HTTP/2 of the server with the config, and wraps
+// its handler to serve h2c unless DisableH2C is true.
+//
+// Notice: the handler of the server must be set before calling it.
+func ConfigureServer(server *http.Server, conf Config) error {
+	h2s := conf.server()
+	if err := http2.ConfigureServer(server, h2s); err != nil {
+		return err
+	}
+
+	if !conf.DisableH2C {
+		server.Handler = newHandler(server.Handler, h2s, conf.DisableUpgrade)
+	}
+	return nil
+}
+
+// Handler returns a new http.Handler to serve h2c with the config,
+// which is used when configuring the http.Server by yourself.
+//
+// Notice: DisableH2C is ignored.
+func Handler(h http.Handler, conf Config) http.Handler {
+	return newHandler(h, conf.server(), conf.DisableUpgrade)
+}
+
+func newHandler(h http.Handler, h2s *http2.Server, disableUpgrade bool) http.Handler {
+	handler := xh2c.NewHandler(h, h2s)
+	if !disableUpgrade {
+		return handler
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Without the header "Upgrade: h2c", the request is not regarded
+		// as the h2c upgrade and is handled as HTTP/1.1.
+		if r.ProtoMajor == 1 && isH2CUpgrade(r.Header) {
+			r.Header.Del("Upgrade")
+			r.Header.Del("Http2-Settings")
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+func isH2CUpgrade(header http.Header) bool {
+	for _, value := range header["Upgrade"] {
+		for _, token := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(token), "h2c") {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,137 @@
+// Copyright 2019 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package h2c
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xgfone/ship"
+	"golang.org/x/net/http2"
+)
+
+func startServer(t *testing.T, conf Config) (s *ship.Ship, addr string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The configurer set by the user is not overridden by Enable.
+	configure := ship.SetServerConfigurer(func(server *http.Server) error {
+		handler := server.Handler
+		server.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Configured", "true")
+			handler.ServeHTTP(w, r)
+		})
+		return nil
+	})
+
+	s = ship.New(configure, Enable(conf), ship.SetSignal([]os.Signal{}),
+		ship.SetLogger(ship.NewNoLevelLogger(ioutil.Discard)))
+	s.R("/proto").GET(func(ctx *ship.Context) error {
+		return ctx.String(http.StatusOK, "%s %s", ctx.Protocol(), ctx.Scheme())
+	})
+
+	go s.Serve(ln)
+	for i := 0; i < 100 && !s.IsReady(); i++ {
+		time.Sleep(time.Millisecond * 10)
+	}
+	return s, ln.Addr().String()
+}
+
+func upgrade(addr string) (status string, err error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	conn.Write([]byte("GET /proto HTTP/1.1\r\nHost: " + addr + "\r\n" +
+		"Connection: Upgrade, HTTP2-Settings\r\nUpgrade: h2c\r\n" +
+		"HTTP2-Settings: AAMAAABkAAQAAP__\r\n\r\n"))
+	status, err = bufio.NewReader(conn).ReadString('\n')
+	return strings.TrimSpace(status), err
+}
+
+func TestH2C(t *testing.T) {
+	s, addr := startServer(t, Config{MaxConcurrentStreams: 100})
+	defer s.Shutdown(context.Background())
+
+	// Prior Knowledge
+	client := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+			return net.Dial(network, addr)
+		},
+	}}
+	resp, err := client.Get("http://" + addr + "/proto")
+	if assert.NoError(t, err) {
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, 2, resp.ProtoMajor)
+		assert.Equal(t, "h2c http", string(body))
+		assert.Equal(t, "true", resp.Header.Get("X-Configured"))
+	}
+
+	// Upgrade
+	status, err := upgrade(addr)
+	assert.NoError(t, err)
+	assert.Equal(t, "HTTP/1.1 101 Switching Protocols", status)
+
+	// HTTP/1.1
+	resp, err = http.Get("http://" + addr + "/proto")
+	if assert.NoError(t, err) {
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, "http/1.1 http", string(body))
+		assert.Equal(t, "true", resp.Header.Get("X-Configured"))
+	}
+
+	// Settings
+	conn, err := net.Dial("tcp", addr)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+	conn.Write([]byte(http2.ClientPreface))
+	framer := http2.NewFramer(conn, conn)
+	framer.WriteSettings()
+	frame, err := framer.ReadFrame()
+	if assert.NoError(t, err) {
+		settings, ok := frame.(*http2.SettingsFrame)
+		if assert.True(t, ok) {
+			v, _ := settings.Value(http2.SettingMaxConcurrentStreams)
+			assert.Equal(t, uint32(100), v)
+		}
+	}
+}
+
+func TestH2CDisableUpgrade(t *testing.T) {
+	s, addr := startServer(t, Config{DisableUpgrade: true})
+	defer s.Shutdown(context.Background())
+
+	status, err := upgrade(addr)
+	assert.NoError(t, err)
+	assert.Equal(t, "HTTP/1.1 200 OK", status)
+}
@@ -113,7 +113,14 @@ func OptionsHandler() Handler {
 	return optionsHandler
 }
 
-func toRouterHandler(handler Handler) func([]string) interface{} {
+// ToRouterHandler converts the handler to the one used by the default router
+// to handle the request with the method not allowed or OPTIONS, which sets
+// the response header "Allow" to the allowed methods. For example,
+//
+//     echo.NewRouter(ship.ToRouterHandler(ship.MethodNotAllowedHandler()),
+//         ship.ToRouterHandler(ship.OptionsHandler()))
+//
+func ToRouterHandler(handler Handler) func([]string) interface{} {
 	return func(methods []string) interface{} {
 		return func(ctx *Context) error {
 			ctx.SetHeader("Allow", strings.Join(methods, ", "))
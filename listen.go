@@ -0,0 +1,96 @@
+// Copyright 2019 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ship
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Listen returns a new listener by the address, which supports the formats:
+//
+//     "host:port", "tcp://host:port"  The TCP address, "" is ":http".
+//     "unix:/path/to/sock"             The Unix domain socket.
+//     "unix:///path/to/sock"           The same as above.
+//     "fd://3"                         The file descriptor inherited from
+//                                      the parent process, such as
+//                                      the supervisor or systemd.
+//
+// For the Unix domain socket, if the socket file exists but no one listens
+// on it, it will be removed before listening.
+//
+// If the process is started by Ship.Restart, it will reuse the listener
+// inherited from the parent process, which listens on the same address.
+func Listen(addr string) (net.Listener, error) {
+	switch {
+	case strings.HasPrefix(addr, "unix:"):
+		path := strings.TrimPrefix(strings.TrimPrefix(addr, "unix:"), "//")
+		if path == "" {
+			return nil, fmt.Errorf("invalid unix socket address '%s'", addr)
+		}
+		if ln := takeInheritedListener("unix", path); ln != nil {
+			return ln, nil
+		}
+		removeStaleUnixSocket(path)
+		return net.Listen("unix", path)
+
+	case strings.HasPrefix(addr, "fd://"):
+		fd, err := strconv.ParseUint(addr[len("fd://"):], 10, 0)
+		if err != nil {
+			return nil, fmt.Errorf("invalid file descriptor address '%s'", addr)
+		}
+		return ListenFD(uintptr(fd), addr)
+
+	case strings.HasPrefix(addr, "tcp://"):
+		addr = addr[len("tcp://"):]
+	}
+
+	if addr == "" {
+		addr = ":http"
+	}
+	if ln := takeInheritedListener("tcp", addr); ln != nil {
+		return ln, nil
+	}
+	return net.Listen("tcp", addr)
+}
+
+// ListenFD returns a new listener from the file descriptor, which is
+// inherited from the parent process. name is the name of the file.
+//
+// The file descriptor will be closed after creating the listener,
+// which has a duplicated one.
+func ListenFD(fd uintptr, name string) (net.Listener, error) {
+	file := os.NewFile(fd, name)
+	if file == nil {
+		return nil, fmt.Errorf("invalid file descriptor '%d'", fd)
+	}
+	defer file.Close()
+	return net.FileListener(file)
+}
+
+func removeStaleUnixSocket(path string) {
+	if fi, err := os.Stat(path); err != nil || fi.Mode()&os.ModeSocket == 0 {
+		return
+	}
+
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return
+	}
+	os.Remove(path)
+}
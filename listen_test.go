@@ -0,0 +1,119 @@
+// Copyright 2019 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ship
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServeMultiListeners(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ship")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	tcpLn, err := Listen("tcp://127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	sock := filepath.Join(dir, "admin.sock")
+	ioutil.WriteFile(sock, nil, 0600) // Not a socket, so it won't be removed.
+	_, err = Listen("unix:" + sock)
+	assert.Error(t, err)
+	os.Remove(sock)
+	unixLn, err := Listen("unix://" + sock)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	s := New(SetSignal([]os.Signal{}), SetLogger(NewNoLevelLogger(ioutil.Discard)))
+	s.R("/").GET(func(ctx *Context) error { return ctx.String(http.StatusOK, "ok") })
+	go s.Serve(tcpLn, unixLn)
+	for i := 0; i < 100 && !s.IsReady(); i++ {
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	get := func(client *http.Client, url string) string {
+		resp, err := client.Get(url)
+		if err != nil {
+			return err.Error()
+		}
+		defer resp.Body.Close()
+		data, _ := ioutil.ReadAll(resp.Body)
+		return string(data)
+	}
+
+	unixClient := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return net.Dial("unix", sock)
+		},
+	}}
+
+	assert.Equal(t, "ok", get(http.DefaultClient, "http://"+tcpLn.Addr().String()))
+	assert.Equal(t, "ok", get(unixClient, "http://unix/"))
+
+	s.Shutdown(context.Background())
+	s.Wait()
+
+	_, err = os.Stat(sock)
+	assert.True(t, os.IsNotExist(err))
+	_, err = net.Dial("tcp", tcpLn.Addr().String())
+	assert.Error(t, err)
+}
+
+func TestServerConfigurer(t *testing.T) {
+	var calls []string
+	configure := func(name string, err error) Option {
+		return SetServerConfigurer(func(*http.Server) error {
+			calls = append(calls, name)
+			return err
+		})
+	}
+
+	s := New(configure("a", nil), SetServerConfigurer(nil), configure("b", nil),
+		configure("c", errors.New("error")), configure("d", nil),
+		SetSignal([]os.Signal{}), SetLogger(NewNoLevelLogger(ioutil.Discard)))
+
+	ln, err := Listen("127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	s.Serve(ln) // Return immediately since the server is not started.
+	assert.Equal(t, []string{"b", "c"}, calls)
+	assert.False(t, s.IsReady())
+
+	// The listener has been closed.
+	_, err = ln.Accept()
+	assert.Error(t, err)
+
+	// The cloned ship inherits the configurers.
+	calls = nil
+	if ln, err = Listen("127.0.0.1:0"); assert.NoError(t, err) {
+		s.Clone().Serve(ln)
+		assert.Equal(t, []string{"b", "c"}, calls)
+	}
+}
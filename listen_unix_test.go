@@ -0,0 +1,56 @@
+// Copyright 2019 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package ship
+
+import (
+	"fmt"
+	"net"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListenFD(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer ln.Close()
+
+	file, err := ln.(*net.TCPListener).File()
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer file.Close()
+
+	// The listener from the file descriptor will close it,
+	// so we pass the duplicated one.
+	fd, err := syscall.Dup(int(file.Fd()))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	fdLn, err := Listen(fmt.Sprintf("fd://%d", fd))
+	if assert.NoError(t, err) {
+		assert.Equal(t, ln.Addr().String(), fdLn.Addr().String())
+		fdLn.Close()
+	}
+
+	_, err = Listen("fd://abc")
+	assert.Error(t, err)
+}
@@ -15,9 +15,17 @@
 package ship
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
 )
 
 // Logger stands for a logger.
@@ -72,3 +80,279 @@ func (l *loggerT) Warn(format string, args ...interface{}) error {
 func (l *loggerT) Error(format string, args ...interface{}) error {
 	return l.output("[E] ", format, args...)
 }
+
+// FieldLogger is a Logger supporting the key-value fields.
+type FieldLogger interface {
+	Logger
+
+	// With returns a child logger with the key-value pairs,
+	// such as "key1", value1, "key2", value2, ..., which will be output
+	// with each log of the child logger after the fields of the parent.
+	With(keysAndValues ...interface{}) FieldLogger
+}
+
+// LoggerWith returns a child logger of logger with the key-value pairs
+// if it is a FieldLogger. Or returns logger itself.
+func LoggerWith(logger Logger, keysAndValues ...interface{}) Logger {
+	if fl, ok := logger.(FieldLogger); ok && len(keysAndValues) > 0 {
+		return fl.With(keysAndValues...)
+	}
+	return logger
+}
+
+// LogLevel is the level of the log.
+type LogLevel int32
+
+// Predefine some log levels.
+const (
+	LvlTrace LogLevel = iota
+	LvlDebug
+	LvlInfo
+	LvlWarn
+	LvlError
+)
+
+var logLevels = []string{"TRACE", "DEBUG", "INFO", "WARN", "ERROR"}
+
+func (l LogLevel) String() string {
+	if l >= LvlTrace && l <= LvlError {
+		return logLevels[l]
+	}
+	return fmt.Sprintf("LEVEL(%d)", int32(l))
+}
+
+// ParseLogLevel parses the level name, such as "debug" or "INFO",
+// to LogLevel.
+func ParseLogLevel(level string) (LogLevel, error) {
+	level = strings.ToUpper(strings.TrimSpace(level))
+	for i, name := range logLevels {
+		if name == level {
+			return LogLevel(i), nil
+		}
+	}
+	if level == "WARNING" {
+		return LvlWarn, nil
+	}
+	return 0, fmt.Errorf("unknown log level '%s'", level)
+}
+
+// LogRecord is a log to be encoded by LogEncoder.
+type LogRecord struct {
+	Time   time.Time
+	Level  LogLevel
+	Msg    string
+	Fields []interface{} // The key-value pairs, such as "key1", value1, ....
+}
+
+// LogEncoder encodes the log record into buf, which should end with '\n'.
+type LogEncoder func(buf *bytes.Buffer, r LogRecord) error
+
+// TextLogEncoder returns a LogEncoder to encode the log in the format of
+// logfmt, such as
+//
+//     time=2019-06-01T12:00:00.000Z level=INFO msg="start the server" addr=:80
+//
+// If timeLayout is empty, it is "2006-01-02T15:04:05.000Z07:00" by default.
+func TextLogEncoder(timeLayout string) LogEncoder {
+	if timeLayout == "" {
+		timeLayout = defaultLogTimeLayout
+	}
+
+	return func(buf *bytes.Buffer, r LogRecord) error {
+		buf.WriteString("time=")
+		buf.WriteString(r.Time.Format(timeLayout))
+		buf.WriteString(" level=")
+		buf.WriteString(r.Level.String())
+		buf.WriteString(" msg=")
+		writeLogTextValue(buf, r.Msg)
+		for i, _len := 0, len(r.Fields); i < _len; i += 2 {
+			buf.WriteByte(' ')
+			buf.WriteString(logFieldKey(r.Fields[i]))
+			buf.WriteByte('=')
+			writeLogTextValue(buf, logFieldString(logFieldValue(r.Fields, i)))
+		}
+		buf.WriteByte('\n')
+		return nil
+	}
+}
+
+// JSONLogEncoder returns a LogEncoder to encode the log as a JSON object
+// in one line, such as
+//
+//     {"time":"2019-06-01T12:00:00.000Z","level":"INFO","msg":"start the server","addr":":80"}
+//
+// The value of the type error will be encoded as the string by Error().
+// If timeLayout is empty, it is "2006-01-02T15:04:05.000Z07:00" by default.
+func JSONLogEncoder(timeLayout string) LogEncoder {
+	if timeLayout == "" {
+		timeLayout = defaultLogTimeLayout
+	}
+
+	return func(buf *bytes.Buffer, r LogRecord) error {
+		buf.WriteString(`{"time":`)
+		buf.WriteString(strconv.Quote(r.Time.Format(timeLayout)))
+		buf.WriteString(`,"level":`)
+		buf.WriteString(strconv.Quote(r.Level.String()))
+		buf.WriteString(`,"msg":`)
+		writeLogJSONValue(buf, r.Msg)
+		for i, _len := 0, len(r.Fields); i < _len; i += 2 {
+			buf.WriteByte(',')
+			writeLogJSONValue(buf, logFieldKey(r.Fields[i]))
+			buf.WriteByte(':')
+
+			switch v := logFieldValue(r.Fields, i).(type) {
+			case error:
+				writeLogJSONValue(buf, v.Error())
+			default:
+				writeLogJSONValue(buf, v)
+			}
+		}
+		buf.WriteString("}\n")
+		return nil
+	}
+}
+
+const defaultLogTimeLayout = "2006-01-02T15:04:05.000Z07:00"
+
+func logFieldKey(key interface{}) string {
+	if s, ok := key.(string); ok {
+		return s
+	}
+	return fmt.Sprint(key)
+}
+
+func logFieldValue(fields []interface{}, keyIndex int) interface{} {
+	if keyIndex+1 < len(fields) {
+		return fields[keyIndex+1]
+	}
+	return nil
+}
+
+func logFieldString(v interface{}) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func writeLogTextValue(buf *bytes.Buffer, s string) {
+	if s == "" || strings.ContainsAny(s, " =\"\t\r\n") || !utf8.ValidString(s) {
+		buf.WriteString(strconv.Quote(s))
+	} else {
+		buf.WriteString(s)
+	}
+}
+
+func writeLogJSONValue(buf *bytes.Buffer, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprint(v))
+	}
+	buf.Write(data)
+}
+
+// LevelLogger is a leveled FieldLogger, the level of which can be changed
+// at run time.
+type LevelLogger interface {
+	FieldLogger
+
+	Level() LogLevel
+	SetLevel(level LogLevel)
+}
+
+// NewLevelLogger returns a new LevelLogger, which only outputs the logs
+// whose levels are not less than level, and encodes them by encoder,
+// which is TextLogEncoder("") by default.
+//
+// The child loggers returned by With share the level with their parent.
+func NewLevelLogger(w io.Writer, level LogLevel, encoder ...LogEncoder) LevelLogger {
+	l := &levelLoggerT{
+		level:   new(int32),
+		writer:  w,
+		encoder: TextLogEncoder(""),
+		lock:    new(sync.Mutex),
+		bufpool: new(sync.Pool),
+	}
+	if len(encoder) > 0 && encoder[0] != nil {
+		l.encoder = encoder[0]
+	}
+	l.bufpool.New = func() interface{} { return bytes.NewBuffer(make([]byte, 0, 256)) }
+	l.SetLevel(level)
+	return l
+}
+
+type levelLoggerT struct {
+	level   *int32
+	fields  []interface{}
+	writer  io.Writer
+	encoder LogEncoder
+	lock    *sync.Mutex
+	bufpool *sync.Pool
+}
+
+func (l *levelLoggerT) Writer() io.Writer {
+	return l.writer
+}
+
+func (l *levelLoggerT) Level() LogLevel {
+	return LogLevel(atomic.LoadInt32(l.level))
+}
+
+func (l *levelLoggerT) SetLevel(level LogLevel) {
+	atomic.StoreInt32(l.level, int32(level))
+}
+
+func (l *levelLoggerT) With(keysAndValues ...interface{}) FieldLogger {
+	nl := *l
+	nl.fields = make([]interface{}, 0, len(l.fields)+len(keysAndValues)+1)
+	nl.fields = append(nl.fields, l.fields...)
+	nl.fields = append(nl.fields, keysAndValues...)
+	if len(keysAndValues)%2 == 1 {
+		nl.fields = append(nl.fields, nil)
+	}
+	return &nl
+}
+
+func (l *levelLoggerT) output(level LogLevel, format string, args []interface{}) error {
+	if level < l.Level() {
+		return nil
+	}
+
+	buf := l.bufpool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer l.bufpool.Put(buf)
+
+	r := LogRecord{Time: time.Now(), Level: level, Msg: fmt.Sprintf(format, args...), Fields: l.fields}
+	if err := l.encoder(buf, r); err != nil {
+		return err
+	}
+
+	l.lock.Lock()
+	_, err := l.writer.Write(buf.Bytes())
+	l.lock.Unlock()
+	return err
+}
+
+func (l *levelLoggerT) Trace(format string, args ...interface{}) error {
+	return l.output(LvlTrace, format, args)
+}
+
+func (l *levelLoggerT) Debug(format string, args ...interface{}) error {
+	return l.output(LvlDebug, format, args)
+}
+
+func (l *levelLoggerT) Info(format string, args ...interface{}) error {
+	return l.output(LvlInfo, format, args)
+}
+
+func (l *levelLoggerT) Warn(format string, args ...interface{}) error {
+	return l.output(LvlWarn, format, args)
+}
+
+func (l *levelLoggerT) Error(format string, args ...interface{}) error {
+	return l.output(LvlError, format, args)
+}
@@ -16,8 +16,15 @@ package ship
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
 )
 
 type writerWrapper struct{ buf *bytes.Buffer }
@@ -64,3 +71,85 @@ func TestLogger(t *testing.T) {
 		t.Errorf("444: %s", ss[4])
 	}
 }
+
+func TestLevelLogger(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	logger := NewLevelLogger(buf, LvlInfo, TextLogEncoder("-"))
+	logger.Debug("debug")
+	logger.Info("info %d", 1)
+	child := logger.With("key", "a b", "num", 123)
+	child.Warn("warn")
+	fields := []interface{}{"odd", "value"}
+	child.With(fields[:1]...).Warn("warn")
+	assert.Equal(t, []interface{}{"odd", "value"}, fields) // Not modified
+	logger.SetLevel(LvlError)
+	child.Warn("warn")
+	logger.With("err", errors.New("error")).Error("error")
+
+	assert.Equal(t, LvlError, logger.Level())
+	assert.Equal(t, `time=- level=INFO msg="info 1"
+time=- level=WARN msg=warn key="a b" num=123
+time=- level=WARN msg=warn key="a b" num=123 odd=""
+time=- level=ERROR msg=error err=error
+`, buf.String())
+
+	buf.Reset()
+	logger = NewLevelLogger(buf, LvlTrace, JSONLogEncoder(time.RFC3339))
+	logger.With("key", "value", "err", errors.New("error"), "num", 123).Trace("trace")
+
+	var ms map[string]interface{}
+	if assert.NoError(t, json.Unmarshal(buf.Bytes(), &ms)) {
+		_, err := time.Parse(time.RFC3339, ms["time"].(string))
+		assert.NoError(t, err)
+		delete(ms, "time")
+		assert.Equal(t, map[string]interface{}{
+			"level": "TRACE",
+			"msg":   "trace",
+			"key":   "value",
+			"err":   "error",
+			"num":   float64(123),
+		}, ms)
+	}
+}
+
+func TestParseLogLevel(t *testing.T) {
+	for _, name := range []string{"trace", "debug", "info", "warn", "error"} {
+		level, err := ParseLogLevel(name)
+		assert.NoError(t, err)
+		assert.Equal(t, strings.ToUpper(name), level.String())
+	}
+
+	level, err := ParseLogLevel("Warning")
+	assert.NoError(t, err)
+	assert.Equal(t, LvlWarn, level)
+
+	_, err = ParseLogLevel("fatal")
+	assert.Error(t, err)
+}
+
+func TestErrorLogWithFields(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	s := New(SetLogger(NewLevelLogger(buf, LvlTrace, JSONLogEncoder(""))))
+	s.R("/users/:id").Name("get_user").GET(func(ctx *Context) error {
+		return PanicError{Value: "boom", Stack: "stack"}
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/123", nil)
+	req.Header.Set(HeaderXRequestID, "abc")
+	s.ServeHTTP(httptest.NewRecorder(), req)
+
+	var ms map[string]interface{}
+	if assert.NoError(t, json.Unmarshal(buf.Bytes(), &ms)) {
+		delete(ms, "time")
+		assert.Equal(t, map[string]interface{}{
+			"level":      "ERROR",
+			"msg":        "boom",
+			"method":     "GET",
+			"path":       "/users/123",
+			"status":     float64(500),
+			"route":      "get_user",
+			"request_id": "abc",
+			"stack":      "stack",
+		}, ms)
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright 2019 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build go1.19
+
+package ship
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxBytesError(t *testing.T) {
+	err := fmt.Errorf("read body: %w", &http.MaxBytesError{Limit: 9})
+	assert.True(t, isMaxBytesError(err))
+	assert.False(t, isMaxBytesError(fmt.Errorf("http: request body too large")))
+
+	err = newBodyBindError(BindError{Source: "json", Err: err})
+	assert.Equal(t, http.StatusRequestEntityTooLarge, ErrorStatusCode(err))
+}
@@ -0,0 +1,313 @@
+// Copyright 2019 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/xgfone/ship"
+)
+
+// Predefine some formats of the access log.
+const (
+	// AccessLogCommon is the Apache Common Log Format, such as
+	//
+	//     127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /index.html HTTP/1.0" 200 2326
+	AccessLogCommon = "common"
+
+	// AccessLogCombined is the Apache Combined Log Format, that's,
+	// AccessLogCommon appended by the referer and the user agent.
+	AccessLogCombined = "combined"
+
+	// AccessLogJSON is the format of the JSON object in one line.
+	AccessLogJSON = "json"
+)
+
+// AccessLogEntry is the information of a request output by the access log.
+type AccessLogEntry struct {
+	Time      time.Time     // The start time of the request.
+	Latency   time.Duration // The time cost to handle the request.
+	Method    string
+	URI       string // The request URI, such as "/path?key=value".
+	Proto     string // The protocol of the request, such as "HTTP/1.1".
+	Host      string
+	User      string // The user of the basic auth.
+	Status    int
+	Size      int64 // The number of the bytes of the response body.
+	RemoteIP  string
+	RealIP    string // The client ip by X-Forwarded-For or X-Real-IP.
+	UserAgent string
+	Referer   string
+	RequestID string
+	Err       error // The error returned by the handler.
+}
+
+// AccessLogConfig is used to configure the access log middleware.
+type AccessLogConfig struct {
+	// Format is the format of the access log, which is one of AccessLogCommon,
+	// AccessLogCombined and AccessLogJSON, or a custom template of the std
+	// library text/template, the data of which is AccessLogEntry,
+	// such as `{{.RealIP}} {{.Method}} {{.URI}} {{.Status}} {{.Latency}}`.
+	//
+	// The default is AccessLogCombined.
+	Format string
+
+	// Writer is the writer to output the access log.
+	//
+	// The default is the writer of the logger of the ship.
+	Writer io.Writer
+
+	// SampleRate is the rate to sample the requests to be logged,
+	// which is in (0, 1). Others mean that all the requests are logged.
+	SampleRate float64
+
+	// SkipPaths is the paths of the requests which are not logged,
+	// such as "/healthz". If the path ends with "*", it is a prefix,
+	// such as "/static/*".
+	SkipPaths []string
+
+	// Now returns the current time, which is time.Now by default.
+	Now func() time.Time
+
+	// HandleError is used to handle the error returned by the handler
+	// by the error handler of the ship before outputting the access log,
+	// then the error is not returned to the outer middlewares, except
+	// ship.ErrSkip and the panic error.
+	//
+	// If false, the access log is output before the error is handled.
+	// So for the error without the response sent, the size is 0 and
+	// the status is classified by ship.ErrorStatusCode, which may be not
+	// the one sent by the error handler, such as ship.ProblemErrorHandler,
+	// the debug mode or a custom error handler.
+	HandleError bool
+}
+
+// AccessLog returns a middleware to output the access log of the request,
+// which records the status and the size of the response.
+//
+// If the config is missing, it will use:
+//
+//   conf := AccessLogConfig{Format: AccessLogCombined, Now: time.Now}
+//
+// See AccessLogConfig.HandleError about the status and the size
+// when the handler returns an error.
+func AccessLog(config ...AccessLogConfig) Middleware {
+	var conf AccessLogConfig
+	if len(config) > 0 {
+		conf = config[0]
+	}
+
+	if conf.Format == "" {
+		conf.Format = AccessLogCombined
+	}
+	if conf.Now == nil {
+		conf.Now = time.Now
+	}
+
+	var format func(*bytes.Buffer, *AccessLogEntry)
+	switch conf.Format {
+	case AccessLogCommon:
+		format = formatCommonAccessLog
+	case AccessLogCombined:
+		format = formatCombinedAccessLog
+	case AccessLogJSON:
+		format = formatJSONAccessLog
+	default:
+		tmpl := template.Must(template.New("accesslog").Parse(conf.Format))
+		format = func(buf *bytes.Buffer, e *AccessLogEntry) {
+			if err := tmpl.Execute(buf, e); err != nil {
+				buf.Reset()
+				buf.WriteString(err.Error())
+			}
+		}
+	}
+
+	skipPaths := make(map[string]struct{}, len(conf.SkipPaths))
+	var skipPrefixes []string
+	for _, path := range conf.SkipPaths {
+		if strings.HasSuffix(path, "*") {
+			skipPrefixes = append(skipPrefixes, path[:len(path)-1])
+		} else {
+			skipPaths[path] = struct{}{}
+		}
+	}
+
+	skip := func(path string) bool {
+		if _, ok := skipPaths[path]; ok {
+			return true
+		}
+		for _, prefix := range skipPrefixes {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var lock sync.Mutex
+	return func(next ship.Handler) ship.Handler {
+		return func(ctx *ship.Context) (err error) {
+			req := ctx.Request()
+			if skip(req.URL.Path) ||
+				(conf.SampleRate > 0 && conf.SampleRate < 1 && rand.Float64() >= conf.SampleRate) {
+				return next(ctx)
+			}
+
+			start := conf.Now()
+			err = next(ctx)
+
+			var handled bool
+			if conf.HandleError && err != nil && err != ship.ErrSkip {
+				if _, ok := ship.AsPanicError(err); !ok {
+					ctx.HandleError(err)
+					handled = true
+				}
+			}
+
+			e := AccessLogEntry{
+				Time:      start,
+				Latency:   conf.Now().Sub(start),
+				Method:    req.Method,
+				URI:       req.URL.RequestURI(),
+				Proto:     req.Proto,
+				Host:      req.Host,
+				Status:    ctx.StatusCode(),
+				Size:      ctx.ResponseSize(),
+				RealIP:    ctx.RealIP(),
+				UserAgent: req.UserAgent(),
+				Referer:   req.Referer(),
+				RequestID: req.Header.Get(ship.HeaderXRequestID),
+				Err:       err,
+			}
+			e.RemoteIP, _, _ = net.SplitHostPort(req.RemoteAddr)
+			e.User, _, _ = req.BasicAuth()
+			if e.Status == 0 {
+				if err == nil || err == ship.ErrSkip {
+					e.Status = http.StatusOK
+				} else {
+					e.Status = ship.ErrorStatusCode(err)
+				}
+			}
+
+			buf := ctx.AcquireBuffer()
+			format(buf, &e)
+			if buf.Len() == 0 || buf.Bytes()[buf.Len()-1] != '\n' {
+				buf.WriteByte('\n')
+			}
+
+			w := conf.Writer
+			if w == nil {
+				w = ctx.Logger().Writer()
+			}
+			lock.Lock()
+			w.Write(buf.Bytes())
+			lock.Unlock()
+			ctx.ReleaseBuffer(buf)
+
+			if handled {
+				err = nil
+			}
+			return
+		}
+	}
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func formatCommonAccessLog(buf *bytes.Buffer, e *AccessLogEntry) {
+	buf.WriteString(orDash(e.RealIP))
+	buf.WriteString(" - ")
+	buf.WriteString(orDash(e.User))
+	buf.WriteString(" [")
+	buf.WriteString(e.Time.Format("02/Jan/2006:15:04:05 -0700"))
+	buf.WriteString(`] "`)
+	buf.WriteString(e.Method)
+	buf.WriteByte(' ')
+	buf.WriteString(e.URI)
+	buf.WriteByte(' ')
+	buf.WriteString(e.Proto)
+	buf.WriteString(`" `)
+	buf.WriteString(strconv.Itoa(e.Status))
+	buf.WriteByte(' ')
+	if e.Size == 0 {
+		buf.WriteByte('-')
+	} else {
+		buf.WriteString(strconv.FormatInt(e.Size, 10))
+	}
+}
+
+func formatCombinedAccessLog(buf *bytes.Buffer, e *AccessLogEntry) {
+	formatCommonAccessLog(buf, e)
+	buf.WriteByte(' ')
+	buf.WriteString(strconv.Quote(orDash(e.Referer)))
+	buf.WriteByte(' ')
+	buf.WriteString(strconv.Quote(orDash(e.UserAgent)))
+}
+
+func formatJSONAccessLog(buf *bytes.Buffer, e *AccessLogEntry) {
+	var err string
+	if e.Err != nil {
+		err = e.Err.Error()
+	}
+
+	json.NewEncoder(buf).Encode(struct {
+		Time      string `json:"time"`
+		Latency   string `json:"latency"`
+		Method    string `json:"method"`
+		URI       string `json:"uri"`
+		Proto     string `json:"proto"`
+		Host      string `json:"host"`
+		User      string `json:"user,omitempty"`
+		Status    int    `json:"status"`
+		Size      int64  `json:"size"`
+		RemoteIP  string `json:"remote_ip"`
+		RealIP    string `json:"real_ip"`
+		UserAgent string `json:"user_agent,omitempty"`
+		Referer   string `json:"referer,omitempty"`
+		RequestID string `json:"request_id,omitempty"`
+		Err       string `json:"error,omitempty"`
+	}{
+		Time:      e.Time.Format(time.RFC3339Nano),
+		Latency:   e.Latency.String(),
+		Method:    e.Method,
+		URI:       e.URI,
+		Proto:     e.Proto,
+		Host:      e.Host,
+		User:      e.User,
+		Status:    e.Status,
+		Size:      e.Size,
+		RemoteIP:  e.RemoteIP,
+		RealIP:    e.RealIP,
+		UserAgent: e.UserAgent,
+		Referer:   e.Referer,
+		RequestID: e.RequestID,
+		Err:       err,
+	})
+}
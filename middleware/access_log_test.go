@@ -0,0 +1,160 @@
+// Copyright 2019 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xgfone/ship"
+)
+
+func TestAccessLog(t *testing.T) {
+	startTime := time.Date(2019, time.June, 1, 12, 0, 0, 0, time.UTC)
+	now := func() time.Time {
+		startTime = startTime.Add(time.Millisecond)
+		return startTime
+	}
+
+	buf := bytes.NewBuffer(nil)
+	newShip := func(format string) *ship.Ship {
+		s := ship.New(ship.DisableErrorLog(true))
+		s.Use(AccessLog(AccessLogConfig{
+			Format:    format,
+			Writer:    buf,
+			Now:       now,
+			SkipPaths: []string{"/healthz", "/static/*"},
+		}))
+		s.Route("/ok").GET(func(ctx *ship.Context) error {
+			return ctx.String(http.StatusCreated, "hello")
+		})
+		s.Route("/err").GET(func(ctx *ship.Context) error {
+			return ship.ErrBadRequest
+		})
+		s.Route("/healthz").GET(func(ctx *ship.Context) error { return nil })
+		s.Route("/static/*").GET(func(ctx *ship.Context) error { return nil })
+		return s
+	}
+
+	serve := func(s *ship.Ship, path string) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set(ship.HeaderUserAgent, "test")
+		req.Header.Set(ship.HeaderXRequestID, "abc")
+		req.SetBasicAuth("aaron", "password")
+		s.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	s := newShip(AccessLogCommon)
+	serve(s, "/ok?k=v")
+	serve(s, "/err")
+	serve(s, "/healthz")
+	serve(s, "/static/a.js")
+	assert.Equal(t, `192.0.2.1 - aaron [01/Jun/2019:12:00:00 +0000] "GET /ok?k=v HTTP/1.1" 201 5
+192.0.2.1 - aaron [01/Jun/2019:12:00:00 +0000] "GET /err HTTP/1.1" 400 -
+`, buf.String())
+
+	buf.Reset()
+	serve(newShip(""), "/ok")
+	assert.Equal(t, `192.0.2.1 - aaron [01/Jun/2019:12:00:00 +0000] "GET /ok HTTP/1.1" 201 5 "-" "test"`+"\n", buf.String())
+
+	buf.Reset()
+	serve(newShip(AccessLogJSON), "/err")
+	var ms map[string]interface{}
+	if assert.NoError(t, json.Unmarshal(buf.Bytes(), &ms)) {
+		assert.Equal(t, "2019-06-01T12:00:00.007Z", ms["time"])
+		assert.Equal(t, "1ms", ms["latency"])
+		assert.Equal(t, float64(400), ms["status"])
+		assert.Equal(t, float64(0), ms["size"])
+		assert.Equal(t, "/err", ms["uri"])
+		assert.Equal(t, "aaron", ms["user"])
+		assert.Equal(t, "192.0.2.1", ms["remote_ip"])
+		assert.Equal(t, "192.0.2.1", ms["real_ip"])
+		assert.Equal(t, "test", ms["user_agent"])
+		assert.Equal(t, "abc", ms["request_id"])
+		assert.Equal(t, "code=400, msg=''", ms["error"])
+	}
+
+	buf.Reset()
+	serve(newShip("{{.Method}} {{.URI}} {{.Status}} {{.Size}} {{.Latency}}"), "/ok")
+	assert.Equal(t, "GET /ok 201 5 1ms\n", buf.String())
+}
+
+func TestAccessLogHandleError(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	handleError := ship.SetErrorHandler(func(ctx *ship.Context, err error) {
+		if !ctx.IsResponded() {
+			ctx.String(http.StatusTeapot, "teapot")
+		}
+	})
+
+	for handle, expected := range map[bool]string{
+		false: "500 0\n",
+		true:  "418 6\n",
+	} {
+		buf.Reset()
+		s := ship.New(handleError)
+		s.Use(AccessLog(AccessLogConfig{
+			Format:      "{{.Status}} {{.Size}}",
+			Writer:      buf,
+			HandleError: handle,
+		}))
+		s.Route("/").GET(func(ctx *ship.Context) error { return fmt.Errorf("error") })
+
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, http.StatusTeapot, rec.Code)
+		assert.Equal(t, "teapot", rec.Body.String())
+		assert.Equal(t, expected, buf.String())
+	}
+}
+
+func TestAccessLogSampleRate(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	s := ship.New()
+	s.Use(AccessLog(AccessLogConfig{Writer: buf, SampleRate: 0.000001}))
+	s.Route("/").GET(func(ctx *ship.Context) error { return nil })
+
+	for i := 0; i < 100; i++ {
+		s.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+	assert.True(t, strings.Count(buf.String(), "\n") < 10)
+}
+
+func TestAccessLogWithGzip(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	s := ship.New()
+	s.Use(AccessLog(AccessLogConfig{Writer: buf, Format: "{{.Status}} {{.Size}}"}), Gzip())
+
+	s.Route("/").GET(func(ctx *ship.Context) error {
+		return ctx.String(http.StatusOK, strings.Repeat("a", 1000))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(ship.HeaderAcceptEncoding, "gzip")
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+
+	// The size is the number of the compressed bytes, not plus the origin.
+	assert.Equal(t, "gzip", rec.Header().Get(ship.HeaderContentEncoding))
+	assert.True(t, rec.Body.Len() < 100)
+	assert.Equal(t, fmt.Sprintf("200 %d\n", rec.Body.Len()), buf.String())
+}
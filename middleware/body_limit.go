@@ -65,9 +65,17 @@ func newLimitedReaderPool(limit int64) sync.Pool {
 }
 
 func (lr *limitedReader) Read(b []byte) (n int, err error) {
+	if lr.read > lr.limit {
+		return 0, ship.ErrStatusRequestEntityTooLarge
+	}
+
 	n, err = lr.reader.Read(b)
 	lr.read += int64(n)
 	if lr.read > lr.limit {
+		// Discard the data beyond the limit, and return the error for all
+		// the later reading, so that the decoder, such as json.Decoder,
+		// does not regard the truncated body as a complete one.
+		n -= int(lr.read - lr.limit)
 		return n, ship.ErrStatusRequestEntityTooLarge
 	}
 	return
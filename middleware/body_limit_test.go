@@ -93,3 +93,25 @@ func TestBodyLimit(t *testing.T) {
 	he = BodyLimit(6)(handler)(ctx).(ship.HTTPError)
 	assert.Equal(http.StatusRequestEntityTooLarge, he.Code)
 }
+
+func TestBodyLimitWithBinder(t *testing.T) {
+	s := ship.New()
+	s.Use(BodyLimit(8))
+	s.Route("/").POST(func(ctx *ship.Context) error {
+		var v map[string]interface{}
+		return ctx.Bind(&v)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(`{"name":"Aaron"}`))
+	req.Header.Set(ship.HeaderContentType, ship.MIMEApplicationJSON)
+	req.ContentLength = -1
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(`{"a":1`))
+	req.Header.Set(ship.HeaderContentType, ship.MIMEApplicationJSON)
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -89,7 +89,7 @@ func CSRF(config ...CSRFConfig) Middleware {
 				// Validate token only for requests which are not defined as 'safe' by RFC7231
 				clientToken, err := conf.GetTokenFromRequest(ctx)
 				if err != nil {
-					if _, ok := err.(ship.HTTPError); ok {
+					if _, ok := ship.AsHTTPError(err); ok {
 						return err
 					}
 					return ship.NewHTTPError(http.StatusBadRequest, err.Error())
@@ -22,6 +22,10 @@ import (
 
 // Logger returns a new logger middleware that will log the request.
 //
+// If the logger of the context is ship.FieldLogger, the request information
+// is output as the fields, such as url, starttime, cost and status, besides
+// the fields of the request logger. Or it is formatted into the message.
+//
 // By default getTime is time.Now().
 func Logger(now ...func() time.Time) Middleware {
 	_now := time.Now
@@ -36,7 +40,9 @@ func Logger(now ...func() time.Time) Middleware {
 			end := _now().Sub(start).String()
 
 			req := ctx.Request()
-			if err == nil {
+			if logger, ok := ctx.Logger().(ship.FieldLogger); ok {
+				logRequest(logger, ctx, start, end, err)
+			} else if err == nil {
 				ctx.Logger().Info("method=%s, url=%s, starttime=%d, cost=%s",
 					req.Method, req.URL.RequestURI(), start.Unix(), end)
 			} else {
@@ -48,3 +54,15 @@ func Logger(now ...func() time.Time) Middleware {
 		}
 	}
 }
+
+func logRequest(logger ship.FieldLogger, ctx *ship.Context, start time.Time,
+	cost string, err error) {
+	fields := []interface{}{"url", ctx.Request().URL.RequestURI(),
+		"starttime", start.Unix(), "cost", cost}
+	if err == nil {
+		logger.With(fields...).Info("request")
+	} else {
+		fields = append(fields, "status", ship.ErrorStatusCode(err), "err", err)
+		logger.With(fields...).Error("request")
+	}
+}
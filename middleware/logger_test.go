@@ -59,3 +59,23 @@ func TestLogger(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestLoggerWithFields(t *testing.T) {
+	bs := bytes.NewBuffer(nil)
+	router := ship.New(ship.SetLogger(ship.NewLevelLogger(bs, ship.LvlInfo, ship.TextLogEncoder("-"))))
+	router.Use(RequestID(func() string { return "abc" }), Logger())
+	router.Route("/test").Name("test").GET(func(ctx *ship.Context) error {
+		return ship.ErrBadRequest.NewMsg("bad")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	line := strings.TrimSpace(bs.String())
+	if !strings.HasPrefix(line, "time=- level=ERROR msg=request method=GET path=/test route=test request_id=abc url=/test starttime=") {
+		t.Error(line)
+	}
+	if !strings.HasSuffix(line, " status=400 err=\"code=400, msg='bad'\"") {
+		t.Error(line)
+	}
+}
@@ -15,33 +15,44 @@
 package middleware
 
 import (
-	"fmt"
+	"net/http"
+	"runtime/debug"
 
 	"github.com/xgfone/ship"
 )
 
-func handlePanic(ctx *ship.Context, err interface{}) {
-	if logger := ctx.Logger(); logger != nil {
-		logger.Error("%v", err)
-	}
-}
-
 // Recover returns a middleware to wrap the panic.
 //
-// Change:
-//    1. Ignore the argument handle. In order to keep the backward compatibility,
-//       we don't remove it until the next major version.
-//    2. This middleware only recovers the panic and returns it as an error.
+// It recovers the panic and returns it as ship.PanicError with the stack,
+// so the error handler and the logger can report it. If handle is given,
+// it will be called with the ship.PanicError before returning it,
+// for example, to report the panic to the monitor system.
+//
+// Notice:
+//    1. http.ErrAbortHandler is panicked again, which is used to abort
+//       the handler and close the connection.
+//    2. If the response has been written partly when panicking, the ship
+//       will close the connection after handling the error, because the
+//       client cannot know the response is incomplete.
 func Recover(handle ...func(*ship.Context, interface{})) Middleware {
+	var handlePanic func(*ship.Context, interface{})
+	if len(handle) > 0 {
+		handlePanic = handle[0]
+	}
+
 	return func(next ship.Handler) ship.Handler {
 		return func(ctx *ship.Context) (err error) {
 			defer func() {
-				switch e := recover().(type) {
+				switch e := recover(); e {
 				case nil:
-				case error:
-					err = e
+				case http.ErrAbortHandler:
+					panic(e)
 				default:
-					err = fmt.Errorf("%v", e)
+					pe := ship.PanicError{Value: e, Stack: string(debug.Stack())}
+					if handlePanic != nil {
+						handlePanic(ctx, pe)
+					}
+					err = pe
 				}
 			}()
 			return next(ctx)
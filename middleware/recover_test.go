@@ -20,6 +20,7 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/xgfone/ship"
 )
 
@@ -41,3 +42,50 @@ func TestRecover(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestRecoverWithHandler(t *testing.T) {
+	var handled interface{}
+	var handledErr error
+	router := ship.New(ship.SetErrorHandler(func(ctx *ship.Context, err error) {
+		handledErr = err
+	})).Use(Recover(func(ctx *ship.Context, v interface{}) { handled = v }))
+
+	router.Route("/panic").GET(func(ctx *ship.Context) error {
+		panic("test panic")
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	pe, ok := handled.(ship.PanicError)
+	assert.True(t, ok)
+	assert.Equal(t, "test panic", pe.Value)
+	assert.Contains(t, pe.Stack, "TestRecoverWithHandler")
+	assert.Equal(t, pe, handledErr)
+}
+
+func TestRecoverAbortHandler(t *testing.T) {
+	var handled bool
+	router := ship.New(ship.DisableErrorLog(true)).
+		Use(Recover(func(*ship.Context, interface{}) { handled = true }))
+	router.Route("/abort").GET(func(ctx *ship.Context) error {
+		panic(http.ErrAbortHandler)
+	})
+	router.Route("/partial").GET(func(ctx *ship.Context) error {
+		ctx.Response().WriteHeader(http.StatusOK)
+		ctx.Response().Write([]byte("partial"))
+		panic("test panic")
+	})
+
+	serve := func(path string) (v interface{}) {
+		defer func() { v = recover() }()
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+		return
+	}
+
+	assert.Equal(t, http.ErrAbortHandler, serve("/abort"))
+	assert.False(t, handled)
+
+	assert.Equal(t, http.ErrAbortHandler, serve("/partial"))
+	assert.True(t, handled)
+}
@@ -38,7 +38,7 @@ func TokenAuth(validator TokenValidator, getToken ...TokenFunc) Middleware {
 		return func(ctx *ship.Context) error {
 			token, err := getAuthToken(ctx)
 			if err != nil {
-				if _, ok := err.(ship.HTTPError); ok {
+				if _, ok := ship.AsHTTPError(err); ok {
 					return err
 				}
 				return ship.NewHTTPError(http.StatusBadRequest, err.Error())
@@ -15,9 +15,12 @@
 package ship
 
 import (
+	"crypto/tls"
+	"net/http"
 	"net/url"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/xgfone/ship/utils"
 )
@@ -88,6 +91,22 @@ func SetBinder(b Binder) Option {
 	}
 }
 
+// SetValidator sets the Validator, which is used to validate the value
+// after binding it by Context.Bind() or Context.BindQuery().
+//
+// It's nil by default, that's, not to validate the value. You can use
+// the builtin tag validator as follow:
+//
+//     router := New(SetValidator(NewTagValidator()))
+//
+// If the value is invalid, the binding will return ErrBadRequest, the Err
+// of which is the error returned by the validator, such as ValidationErrors.
+func SetValidator(v Validator) Option {
+	return func(s *Ship) {
+		s.validator = v
+	}
+}
+
 // SetSession sets the Session, which is `NewMemorySession()` by default.
 func SetSession(session Session) Option {
 	return func(s *Ship) {
@@ -129,6 +148,12 @@ func SetSession(session Session) Option {
 //     ctx.Render("xmlpretty", 200, data)
 //     ctx.Render("index.html", 200, data)
 //
+// For the content negotiation by ctx.Negotiate(code, data), the media types
+// "application/json", "application/xml" and "text/xml" have been added into
+// the negotiation table, and you can add yourself, for example,
+//
+//     mr.AddMediaType("application/yaml", "yaml")
+//
 func SetRenderer(r Renderer) Option {
 	return func(s *Ship) {
 		if r != nil {
@@ -153,6 +178,139 @@ func SetSignal(sigs []os.Signal) Option {
 	}
 }
 
+// SetShutdownDelay sets the delay duration between marking the ship
+// not ready and draining the connections when shutting down the server,
+// which gives the load balancers the time to stop sending the traffic.
+// See Ship.ReadinessHandler.
+//
+// The default is 0, that's, not to delay.
+func SetShutdownDelay(delay time.Duration) Option {
+	return func(s *Ship) {
+		if delay >= 0 {
+			s.shutdownDelay = delay
+		}
+	}
+}
+
+// SetShutdownTimeout sets the timeout to drain the active connections
+// when shutting down the server, after which the connections will be
+// closed forcibly. 0 is to wait until all the connections are drained,
+// so a hung connection may block the shutdown and the restart forever.
+//
+// The default is 30s.
+func SetShutdownTimeout(timeout time.Duration) Option {
+	return func(s *Ship) {
+		if timeout >= 0 {
+			s.shutdownTimeout = timeout
+		}
+	}
+}
+
+// SetShutdownHookTimeout sets the timeout of the shutdown hooks of each phase,
+// which is used as the deadline of the context passed to the hooks.
+//
+// The default is 10s.
+func SetShutdownHookTimeout(timeout time.Duration) Option {
+	return func(s *Ship) {
+		if timeout > 0 {
+			s.shutdownHookTimeout = timeout
+		}
+	}
+}
+
+// SetRestartSignal sets the signals to restart the server gracefully
+// without dropping the connections. See Ship.Restart.
+//
+// The default is nil, that's, not to restart by the signal.
+// For example,
+//
+//     ship.New(ship.SetRestartSignal(syscall.SIGHUP))
+//
+func SetRestartSignal(sigs ...os.Signal) Option {
+	return func(s *Ship) {
+		s.rsignals = sigs
+	}
+}
+
+// SetRestartTimeout sets the timeout to wait for the new process to be ready
+// when restarting the server. See Ship.Restart.
+//
+// The default is 30s.
+func SetRestartTimeout(timeout time.Duration) Option {
+	return func(s *Ship) {
+		if timeout > 0 {
+			s.rtimeout = timeout
+		}
+	}
+}
+
+// SetTLSConfig sets the base TLS configuration to serve HTTPS, such as
+// the minimum version and the cipher suites. If MinVersion is 0,
+// it is tls.VersionTLS12.
+//
+// If the certificates are set by SetTLSCertFile, GetCertificate will be
+// overridden to select the certificate by SNI. See SetTLSCertFile.
+func SetTLSConfig(config *tls.Config) Option {
+	return func(s *Ship) {
+		s.tlsConfig = config
+	}
+}
+
+// SetTLSCertFile sets the certificate and key files, which will be loaded
+// when starting the server, and can be reloaded by SetTLSReload
+// or Ship.ReloadCertificates.
+//
+// It can be used by the virtual host, for example,
+//
+//     router := ship.New(ship.SetTLSCertFile("default.crt", "default.key"))
+//     router.VHost("www.example.com").Configure(
+//         ship.SetTLSCertFile("example.crt", "example.key"))
+//
+// Then the certificate is selected by the SNI server name from the virtual
+// hosts, and the certificate of the ship is used if no virtual host matches.
+func SetTLSCertFile(certFile, keyFile string) Option {
+	return func(s *Ship) {
+		if certFile != "" && keyFile != "" {
+			s.tlsCert = newTLSCertFile(certFile, keyFile)
+		}
+	}
+}
+
+// SetTLSReload sets the interval to check whether the certificate files
+// have changed and reload them, and the signals to reload the certificates.
+//
+// The default is 0 and nil, that's, not to reload the certificates.
+// For example,
+//
+//     ship.New(ship.SetTLSReload(time.Minute, syscall.SIGUSR1))
+//
+func SetTLSReload(interval time.Duration, sigs ...os.Signal) Option {
+	return func(s *Ship) {
+		s.tlsReloadInterval = interval
+		s.tlsReloadSignals = sigs
+	}
+}
+
+// SetServerConfigurer appends the function to configure the HTTP server
+// before serving, which is called after the handler and the TLS config
+// of the server have been set. For example, it may be used to enable HTTP/2
+// or wrap the handler of the server.
+//
+// It may be set more than once, and the configurers are called in turn
+// by the order that they are set, so the later sees the handler wrapped
+// by the former. If one returns an error, the rest will not be called
+// and the server will not be started. The nil configurer clears all
+// the configurers set before.
+func SetServerConfigurer(configure func(*http.Server) error) Option {
+	return func(s *Ship) {
+		if configure == nil {
+			s.configures = nil
+		} else {
+			s.configures = append(s.configures, configure)
+		}
+	}
+}
+
 // SetBufferSize sets the buffer size, which is used to initializing the buffer pool.
 //
 // The default is 2048.
@@ -184,6 +342,31 @@ func SetMaxMiddlewareNum(num int) Option {
 	}
 }
 
+// SetMaxBindIndex sets the maximum index of the slice element when binding
+// the indexed key, such as "items[100].sku", which is used to prevent
+// a hostile request from allocating a huge slice.
+//
+// The default is 256.
+func SetMaxBindIndex(index int) Option {
+	return func(s *Ship) {
+		if index >= 0 {
+			s.bindLimits.maxIndex = index
+		}
+	}
+}
+
+// SetMaxBindDepth sets the maximum depth of the nested key when binding,
+// such as "a.b.c" whose depth is 2.
+//
+// The default is 8.
+func SetMaxBindDepth(depth int) Option {
+	return func(s *Ship) {
+		if depth >= 0 {
+			s.bindLimits.maxDepth = depth
+		}
+	}
+}
+
 // EnableCtxHTTPContext sets whether to inject the Context into the HTTP request
 // as the http context, then you can use `GetContext(httpReq)` to get the Context.
 func EnableCtxHTTPContext(enable bool) Option {
@@ -249,7 +432,7 @@ func SetOptionsHandler(h Handler) Option {
 		if h != nil {
 			s.optionsHandler = h
 			if s.isDefaultRouter {
-				s.router = s.newRouter()
+				s.setRouter(s.newRouter())
 			}
 		}
 	}
@@ -263,7 +446,7 @@ func SetMethodNotAllowedHandler(h Handler) Option {
 		if h != nil {
 			s.methodNotAllowedHandler = h
 			if s.isDefaultRouter {
-				s.router = s.newRouter()
+				s.setRouter(s.newRouter())
 			}
 		}
 	}
@@ -281,7 +464,7 @@ func SetNewRouter(newRouter func() Router) Option {
 			s.newRouter = s.defaultNewRouter
 			s.isDefaultRouter = true
 		}
-		s.router = s.newRouter()
+		s.setRouter(s.newRouter())
 	}
 }
 
@@ -309,7 +492,8 @@ func SetCtxHandler(handler func(*Context, ...interface{}) error) Option {
 // returned by the handler or the middleware at last.
 //
 // The default will send the response to the peer if the error is a HTTPError.
-// Or only send 500 if no response.
+// Or only send 500 if no response. You can use ProblemErrorHandler instead
+// to send the problem details defined by RFC 7807.
 func SetErrorHandler(handler func(*Context, error)) Option {
 	return func(s *Ship) {
 		if handler != nil {
@@ -326,7 +510,8 @@ func DisableErrorLog(disabled bool) Option {
 }
 
 // SetBindQuery sets the query binder to bind the query to a value,
-// which is `BindURLValues(v, d, "query")` by default.
+// which is like `BindURLValues(v, d, "query")` by default, but uses
+// the limits set by SetMaxBindIndex and SetMaxBindDepth.
 func SetBindQuery(bind func(interface{}, url.Values) error) Option {
 	return func(s *Ship) {
 		if bind != nil {
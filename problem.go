@@ -0,0 +1,229 @@
+// Copyright 2019 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ship
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Problem is the problem details for HTTP APIs defined by RFC 7807.
+type Problem struct {
+	Type     string // The URI reference identifying the problem type.
+	Title    string // The short, human-readable summary of the problem type.
+	Status   int    // The HTTP status code.
+	Detail   string // The human-readable explanation of this problem.
+	Instance string // The URI reference identifying this problem.
+
+	// Extensions is the extension members, such as "code" and "errors".
+	Extensions map[string]interface{}
+}
+
+// NewProblem converts the error to the problem details.
+//
+// For HTTPError, its extension fields are used as the extension members,
+// and ErrCode is used as the extension member "code". ValidationErrors and
+// BindError as the inner error are used as the extension member "errors".
+//
+// For the server error, the inner error is hidden unless debug is true.
+// And other errors are regarded as 500.
+func NewProblem(err error, debug bool) Problem {
+	e, ok := toHTTPError(err)
+	if !ok {
+		e = ErrInternalServerError.NewError(err)
+	}
+
+	p := Problem{
+		Type:       e.Type,
+		Title:      http.StatusText(e.Code),
+		Status:     e.Code,
+		Detail:     e.Msg,
+		Extensions: e.Fields(),
+	}
+	if p.Type == "" {
+		p.Type = "about:blank"
+	}
+
+	if e.Err != nil && (e.Code < 500 || debug) {
+		if p.Detail == "" {
+			p.Detail = e.Err.Error()
+		} else {
+			p.Detail = fmt.Sprintf("%s: %s", p.Detail, e.Err)
+		}
+
+		switch ie := e.Err.(type) {
+		case ValidationErrors:
+			p.setExtension("errors", ie)
+		case FieldError:
+			p.setExtension("errors", ValidationErrors{ie})
+		case BindError:
+			if ie.Field != "" {
+				p.setExtension("errors", []BindError{ie})
+			}
+		}
+	}
+
+	if e.ErrCode != "" {
+		p.setExtension("code", e.ErrCode)
+	}
+
+	return p
+}
+
+func (p *Problem) setExtension(key string, value interface{}) {
+	if p.Extensions == nil {
+		p.Extensions = make(map[string]interface{}, 2)
+	}
+	if _, ok := p.Extensions[key]; !ok {
+		p.Extensions[key] = value
+	}
+}
+
+func (p Problem) members() map[string]interface{} {
+	ms := make(map[string]interface{}, len(p.Extensions)+5)
+	for key, value := range p.Extensions {
+		ms[key] = value
+	}
+
+	ms["type"] = p.Type
+	ms["title"] = p.Title
+	ms["status"] = p.Status
+	if p.Detail != "" {
+		ms["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		ms["instance"] = p.Instance
+	}
+	return ms
+}
+
+// MarshalJSON implements the interface json.Marshaler.
+func (p Problem) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.members())
+}
+
+// MarshalXML implements the interface xml.Marshaler, the format of which
+// is defined by the appendix A of RFC 7807.
+func (p Problem) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start = xml.StartElement{
+		Name: xml.Name{Local: "problem"},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: "urn:ietf:rfc:7807"}},
+	}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+
+	ms := p.members()
+	keys := make([]string, 0, len(ms))
+	for key := range ms {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		elem := xml.StartElement{Name: xml.Name{Local: key}}
+		if err := e.EncodeElement(ms[key], elem); err != nil {
+			return err
+		}
+	}
+
+	return e.EncodeToken(start.End())
+}
+
+// String returns the plain text of the problem, such as
+// "404 Not Found: the user does not exist".
+func (p Problem) String() string {
+	if p.Detail == "" {
+		return fmt.Sprintf("%d %s", p.Status, p.Title)
+	}
+	return fmt.Sprintf("%d %s: %s", p.Status, p.Title, p.Detail)
+}
+
+// ProblemErrorHandler is an error handler to respond the error as the problem
+// details defined by RFC 7807, the format of which is negotiated by the
+// request header Accept among "application/problem+json",
+// "application/problem+xml" and "text/plain". It is JSON by default.
+//
+// The member "instance" is the path of the request URL.
+//
+// You can use it by the option SetErrorHandler, for example,
+//
+//     s := New(SetErrorHandler(ProblemErrorHandler))
+//
+func ProblemErrorHandler(ctx *Context, err error) {
+	switch err {
+	case nil, ErrSkip:
+		return
+	}
+
+	if !ctx.IsResponded() {
+		p := NewProblem(err, ctx.IsDebug())
+		p.Instance = ctx.Request().URL.Path
+
+		var b []byte
+		var ct string
+		var merr error
+		switch negotiateProblem(ctx.Accept()) {
+		case "xml":
+			// Some extension members, such as map, cannot be marshaled
+			// to XML, so fall back to JSON.
+			if b, merr = xml.Marshal(p); merr == nil {
+				ct, b = MIMEApplicationProblemXML, append([]byte(xml.Header), b...)
+			}
+		case "text":
+			ct, b = MIMETextPlainCharsetUTF8, []byte(p.String())
+		}
+
+		if ct == "" {
+			ct = MIMEApplicationProblemJSON
+			b, merr = json.Marshal(p)
+		}
+
+		if merr != nil {
+			ctx.NoContent(http.StatusInternalServerError)
+			err = fmt.Errorf("%s; and failed to marshal the problem: %s", err, merr)
+		} else {
+			ctx.Blob(p.Status, ct, b)
+		}
+	}
+
+	if !ctx.ship.disableErrorLog {
+		ctx.Logger().Error("%s", err)
+	}
+}
+
+func negotiateProblem(accepts []string) string {
+	for _, accept := range accepts {
+		switch accept {
+		case "", "application/", MIMEApplicationJSON, MIMEApplicationProblemJSON:
+			return "json"
+		case MIMEApplicationXML, MIMETextXML, MIMEApplicationProblemXML:
+			return "xml"
+		case MIMETextPlain, "text/":
+			return "text"
+		default:
+			if strings.HasSuffix(accept, "+json") {
+				return "json"
+			} else if strings.HasSuffix(accept, "+xml") {
+				return "xml"
+			}
+		}
+	}
+	return "json"
+}
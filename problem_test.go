@@ -0,0 +1,86 @@
+// Copyright 2019 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ship
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProblemErrorHandler(t *testing.T) {
+	s := New(SetErrorHandler(ProblemErrorHandler), SetValidator(NewTagValidator()),
+		DisableErrorLog(true))
+	s.Route("/users/:id").GET(func(ctx *Context) error {
+		return ErrNotFound.NewMsg("the user does not exist").
+			NewErrCode("user_not_found").NewField("id", ctx.Param("id"))
+	})
+	s.Route("/users").POST(func(ctx *Context) error {
+		var req struct {
+			Name string `json:"name" validate:"required"`
+		}
+		return ctx.Bind(&req)
+	})
+	s.Route("/panic").GET(func(ctx *Context) error {
+		return errors.New("database password is wrong")
+	})
+
+	serve := func(method, path, accept, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+		if accept != "" {
+			req.Header.Set(HeaderAccept, accept)
+		}
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve(http.MethodGet, "/users/123", "", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, MIMEApplicationProblemJSON, rec.Header().Get(HeaderContentType))
+	var p map[string]interface{}
+	if assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p)) {
+		assert.Equal(t, map[string]interface{}{
+			"type":     "about:blank",
+			"title":    "Not Found",
+			"status":   float64(404),
+			"detail":   "the user does not exist",
+			"instance": "/users/123",
+			"code":     "user_not_found",
+			"id":       "123",
+		}, p)
+	}
+
+	rec = serve(http.MethodPost, "/users", "application/problem+xml", `{}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, MIMEApplicationProblemXML, rec.Header().Get(HeaderContentType))
+	assert.Contains(t, rec.Body.String(), `<problem xmlns="urn:ietf:rfc:7807">`)
+	assert.Contains(t, rec.Body.String(), `<errors><field>Name</field><rule>required</rule></errors>`)
+	assert.Contains(t, rec.Body.String(), `<status>400</status>`)
+
+	rec = serve(http.MethodPost, "/users", "text/plain", `{"name":1}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.True(t, strings.HasPrefix(rec.Body.String(), "400 Bad Request: cannot bind the field 'name'"))
+
+	rec = serve(http.MethodGet, "/panic", "application/json", "")
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "password")
+}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"io"
 	"path"
+	"strings"
 
 	"github.com/xgfone/ship/utils"
 )
@@ -33,6 +34,14 @@ type Renderer interface {
 // MuxRenderer is a multiplexer for kinds of Renderers.
 type MuxRenderer struct {
 	renders map[string]Renderer
+
+	// The negotiation table from the media type to the renderer name.
+	mediaTypes []mediaTypeT
+}
+
+type mediaTypeT struct {
+	mediaType string
+	name      string
 }
 
 // NewMuxRenderer returns a new MuxRenderer.
@@ -82,6 +91,76 @@ func (mr *MuxRenderer) Render(ctx *Context, name string, code int, data interfac
 	return fmt.Errorf("not support the renderer named '%s'", name)
 }
 
+// AddMediaType adds the media type into the negotiation table,
+// which is rendered by the renderer named name.
+//
+// The media type registered firstly has the highest priority when the Accept
+// header is missing or matches more than one media type. For example,
+//
+//     mr.Add("yaml", yamlRenderer)
+//     mr.AddMediaType("application/yaml", "yaml")
+//
+func (mr *MuxRenderer) AddMediaType(mediaType, name string) {
+	if mediaType == "" {
+		panic(errors.New("the media type is empty"))
+	}
+
+	name = mr.fmtSuffix(name)
+	for i := range mr.mediaTypes {
+		if mr.mediaTypes[i].mediaType == mediaType {
+			mr.mediaTypes[i].name = name
+			return
+		}
+	}
+	mr.mediaTypes = append(mr.mediaTypes, mediaTypeT{mediaType: mediaType, name: name})
+}
+
+// DelMediaType removes the media type from the negotiation table.
+func (mr *MuxRenderer) DelMediaType(mediaType string) {
+	for i := range mr.mediaTypes {
+		if mr.mediaTypes[i].mediaType == mediaType {
+			mr.mediaTypes = append(mr.mediaTypes[:i], mr.mediaTypes[i+1:]...)
+			return
+		}
+	}
+}
+
+// MediaTypes returns all the media types in the negotiation table.
+func (mr *MuxRenderer) MediaTypes() []string {
+	mts := make([]string, len(mr.mediaTypes))
+	for i := range mr.mediaTypes {
+		mts[i] = mr.mediaTypes[i].mediaType
+	}
+	return mts
+}
+
+// Negotiate returns the best media type and the name of its renderer
+// by the accepted media types, which is the result of Context.Accept().
+//
+// The media type whose renderer has not been added is ignored.
+// Return ("", "") if no media type is acceptable.
+func (mr *MuxRenderer) Negotiate(accepts []string) (mediaType, name string) {
+	if len(accepts) == 0 {
+		accepts = []string{""} // The missing Accept header is "*/*".
+	}
+
+	for _, accept := range accepts {
+		for _, mt := range mr.mediaTypes {
+			if mr.renders[mt.name] == nil {
+				continue
+			}
+
+			// "" stands for "*/*", and "<MIME_type>/" for "<MIME_type>/*".
+			if accept == "" || accept == mt.mediaType ||
+				(accept[len(accept)-1] == '/' && strings.HasPrefix(mt.mediaType, accept)) {
+				return mt.mediaType, mt.name
+			}
+		}
+	}
+
+	return
+}
+
 type rendererFunc func(*Context, string, int, interface{}) error
 
 func (f rendererFunc) Render(ctx *Context, name string, code int, data interface{}) error {
@@ -190,3 +190,51 @@ func TestXMLPretty(t *testing.T) {
 		t.Error(rec.Body.String())
 	}
 }
+
+func TestContextNegotiate(t *testing.T) {
+	s := New()
+	s.MuxRenderer().Add("text", SimpleRenderer("text", MIMETextPlainCharsetUTF8,
+		func(v interface{}) ([]byte, error) { return []byte(v.(string)), nil }))
+	s.MuxRenderer().AddMediaType(MIMETextPlain, "text")
+	s.Route("/").GET(func(ctx *Context) error { return ctx.Negotiate(200, "data") })
+
+	for accept, body := range map[string]string{
+		"":                                  `"data"`,
+		"*/*":                               `"data"`,
+		"application/xml;q=0.9, text/plain": "data",
+		"text/xml, application/json;q=0.8":  "<string>data</string>",
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(HeaderAccept, accept)
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, body, rec.Body.String())
+		assert.Equal(t, HeaderAccept, rec.Header().Get(HeaderVary))
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(HeaderAccept, "image/png")
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotAcceptable, rec.Code)
+	assert.Equal(t, "acceptable media types: application/json, application/xml, text/xml, text/plain",
+		rec.Body.String())
+}
+
+func TestMuxRendererNegotiateWithoutRenderer(t *testing.T) {
+	mr := NewMuxRenderer()
+	mr.AddMediaType("application/yaml", "yaml") // Add the renderer later.
+	mr.Add("text", SimpleRenderer("text", MIMETextPlainCharsetUTF8,
+		func(v interface{}) ([]byte, error) { return []byte(v.(string)), nil }))
+	mr.AddMediaType(MIMETextPlain, "text")
+
+	for _, accepts := range [][]string{nil, {""}, {"application/yaml", "text/"}} {
+		mediaType, name := mr.Negotiate(accepts)
+		assert.Equal(t, MIMETextPlain, mediaType)
+		assert.Equal(t, "text", name)
+	}
+
+	mediaType, _ := mr.Negotiate([]string{"application/yaml"})
+	assert.Equal(t, "", mediaType)
+}
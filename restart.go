@@ -0,0 +1,343 @@
+// Copyright 2019 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ship
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// EnvListenFDs is the environment variable to pass the number of
+// the listeners, the file descriptors of which start from 3,
+// from the parent process to the child process when restarting.
+const EnvListenFDs = "SHIP_LISTEN_FDS"
+
+// EnvReadyFD is the environment variable to pass the file descriptor
+// of the pipe from the parent process to the child process when restarting,
+// by which the child process notifies the parent that it is ready.
+const EnvReadyFD = "SHIP_READY_FD"
+
+// inheritedListenerTimeout is the maximum time to wait for the inherited
+// listeners to be reused after the first server has started.
+var inheritedListenerTimeout = time.Second * 5
+
+var inherited struct {
+	once    sync.Once
+	lock    sync.Mutex
+	lns     []net.Listener
+	claimed chan struct{} // Closed when all the listeners have been reused.
+	ready   *os.File
+}
+
+func loadInheritedListeners() {
+	num, _ := strconv.Atoi(os.Getenv(EnvListenFDs))
+	os.Unsetenv(EnvListenFDs)
+	for i := 0; i < num; i++ {
+		fd := uintptr(3 + i)
+		if ln, err := ListenFD(fd, "fd://"+strconv.Itoa(int(fd))); err == nil {
+			inherited.lns = append(inherited.lns, ln)
+		}
+	}
+
+	if fd, err := strconv.Atoi(os.Getenv(EnvReadyFD)); err == nil && fd > 2 {
+		inherited.ready = os.NewFile(uintptr(fd), "ready")
+	}
+	os.Unsetenv(EnvReadyFD)
+
+	inherited.claimed = make(chan struct{})
+	if len(inherited.lns) == 0 {
+		close(inherited.claimed)
+	}
+}
+
+// takeInheritedListener returns the listener inherited from the parent
+// process, which listens on the address, or nil.
+func takeInheritedListener(network, addr string) net.Listener {
+	inherited.once.Do(loadInheritedListeners)
+	inherited.lock.Lock()
+	defer inherited.lock.Unlock()
+
+	for i, ln := range inherited.lns {
+		if isSameListenAddr(network, addr, ln.Addr()) {
+			inherited.lns = append(inherited.lns[:i], inherited.lns[i+1:]...)
+			if len(inherited.lns) == 0 {
+				close(inherited.claimed)
+			}
+			return ln
+		}
+	}
+	return nil
+}
+
+// closeInheritedListeners closes the inherited listeners
+// which have not been reused.
+func closeInheritedListeners() {
+	inherited.lock.Lock()
+	lns := inherited.lns
+	inherited.lns = nil
+	inherited.lock.Unlock()
+	for _, ln := range lns {
+		ln.Close()
+	}
+}
+
+// notifyRestartReady notifies the parent process that the child process
+// is ready after all the inherited listeners have been reused, or closes
+// the rest after timeout, which does nothing if not started by Restart.
+func notifyRestartReady() {
+	inherited.once.Do(loadInheritedListeners)
+	inherited.lock.Lock()
+	ready := inherited.ready
+	inherited.ready = nil
+	inherited.lock.Unlock()
+	if ready == nil {
+		return
+	}
+
+	go func() {
+		timer := time.NewTimer(inheritedListenerTimeout)
+		select {
+		case <-inherited.claimed:
+		case <-timer.C:
+		}
+		timer.Stop()
+
+		closeInheritedListeners()
+		ready.Write([]byte{1})
+		ready.Close()
+	}()
+}
+
+func isSameListenAddr(network, addr string, laddr net.Addr) bool {
+	switch la := laddr.(type) {
+	case *net.UnixAddr:
+		return network == "unix" && la.Name == addr
+	case *net.TCPAddr:
+		if network != "tcp" {
+			return false
+		}
+
+		ta, err := net.ResolveTCPAddr("tcp", addr)
+		if err != nil || ta.Port == 0 || ta.Port != la.Port {
+			return false
+		} else if len(ta.IP) == 0 || ta.IP.IsUnspecified() {
+			return len(la.IP) == 0 || la.IP.IsUnspecified()
+		}
+		return ta.IP.Equal(la.IP)
+	default:
+		return false
+	}
+}
+
+// restartShips returns the ship and the ships linked to or cloned from it,
+// which share the lifecycle and are restarted together.
+func (s *Ship) restartShips() []*Ship {
+	ships := []*Ship{s}
+	for i := 0; i < len(ships); i++ {
+		ship := ships[i]
+		ship.lock.RLock()
+		related := make([]*Ship, 0, len(ship.links)+len(ship.clones)+1)
+		related = append(related, ship.links...)
+		related = append(related, ship.clones...)
+		if ship.parent != nil {
+			related = append(related, ship.parent)
+		}
+		ship.lock.RUnlock()
+
+	LOOP:
+		for _, r := range related {
+			for _, ship := range ships {
+				if ship == r {
+					continue LOOP
+				}
+			}
+			ships = append(ships, r)
+		}
+	}
+	return ships
+}
+
+// Restart restarts the server gracefully without dropping the connections.
+//
+// It starts a new process by the current executable and arguments,
+// which inherits the listeners of the ship and the ships linked to
+// or cloned from it by the file descriptors and the environment variable
+// EnvListenFDs. The new process will reuse the inherited listeners
+// by the function Listen, such as Ship.Start, which listen on the same
+// addresses, and close the rest which are not reused in 5s after its first
+// server has started.
+//
+// After the new process has notified that it is ready by the pipe passed
+// by the environment variable EnvReadyFD, it shuts down the current servers
+// gracefully. If the new process exits or is not ready in the timeout set
+// by SetRestartTimeout, it will be killed and the current servers go on
+// serving.
+//
+// Notice: the listeners must have the method "File() (*os.File, error)",
+// such as *net.TCPListener and *net.UnixListener, and it is not supported
+// on Windows.
+func (s *Ship) Restart() error {
+	var lns []net.Listener
+	var ships []*Ship
+	for _, ship := range s.restartShips() {
+		ship.lock.RLock()
+		if len(ship.lns) > 0 {
+			lns = append(lns, ship.lns...)
+			ships = append(ships, ship)
+		}
+		ship.lock.RUnlock()
+	}
+	if len(lns) == 0 {
+		return fmt.Errorf("the server has not been started")
+	}
+
+	files := make([]*os.File, 0, len(lns)+1)
+	defer func() {
+		for _, file := range files {
+			file.Close()
+		}
+	}()
+
+	for _, ln := range lns {
+		fl, ok := ln.(interface{ File() (*os.File, error) })
+		if !ok {
+			return fmt.Errorf("the listener '%s' cannot be inherited", ln.Addr())
+		}
+
+		file, err := fl.File()
+		if err != nil {
+			return err
+		}
+		files = append(files, file)
+	}
+
+	path, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	ready, readyw, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer ready.Close()
+
+	env := make([]string, 0, len(os.Environ())+2)
+	for _, e := range os.Environ() {
+		if !strings.HasPrefix(e, EnvListenFDs+"=") && !strings.HasPrefix(e, EnvReadyFD+"=") {
+			env = append(env, e)
+		}
+	}
+	env = append(env, fmt.Sprintf("%s=%d", EnvListenFDs, len(lns)))
+	env = append(env, fmt.Sprintf("%s=%d", EnvReadyFD, 3+len(lns)))
+
+	cmd := exec.Command(path, os.Args[1:]...)
+	cmd.Env = env
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = append(files, readyw)
+	err = cmd.Start()
+	readyw.Close() // Only the new process holds the writer.
+
+	// Starting the process puts the sockets shared with the listeners
+	// into the blocking mode, so restore them for the current servers.
+	for _, ln := range lns {
+		if sc, ok := ln.(syscall.Conn); ok {
+			if rc, e := sc.SyscallConn(); e == nil {
+				rc.Control(setNonblock)
+			}
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+	go cmd.Wait()
+	s.logger.Info("start the new process [%d] to restart the server", cmd.Process.Pid)
+
+	if err = s.waitRestartReady(ready); err != nil {
+		cmd.Process.Kill()
+		return fmt.Errorf("the new process [%d] %s", cmd.Process.Pid, err)
+	}
+
+	// The socket file has been inherited by the new process,
+	// so it should not be removed when closing the listener.
+	for _, ln := range lns {
+		if ul, ok := ln.(*net.UnixListener); ok {
+			ul.SetUnlinkOnClose(false)
+		}
+	}
+
+	errs := make(chan error, len(ships))
+	for _, ship := range ships {
+		go func(ship *Ship) { errs <- ship.Shutdown(context.Background()) }(ship)
+	}
+	for range ships {
+		if e := <-errs; e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// waitRestartReady waits for the new process to notify that it is ready.
+func (s *Ship) waitRestartReady(ready *os.File) (err error) {
+	result := make(chan error, 1)
+	go func() {
+		// EOF means that the new process has exited.
+		_, err := ready.Read(make([]byte, 1))
+		result <- err
+	}()
+
+	timer := time.NewTimer(s.rtimeout)
+	defer timer.Stop()
+	select {
+	case err = <-result:
+		if err != nil {
+			err = fmt.Errorf("exits before being ready: %s", err)
+		}
+	case <-timer.C:
+		err = fmt.Errorf("is not ready in %s", s.rtimeout)
+	}
+	return
+}
+
+func (s *Ship) handleRestartSignals(sigs ...os.Signal) {
+	ss := make(chan os.Signal, 1)
+	signal.Notify(ss, sigs...)
+	defer signal.Stop(ss)
+
+	for {
+		select {
+		case <-ss:
+			if err := s.Restart(); err != nil {
+				s.logger.Error("failed to restart the server: %s", err)
+				continue
+			}
+		case <-s.done:
+		}
+		return
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright 2019 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package ship
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	envTestRestartAddr = "SHIP_TEST_RESTART_ADDR"
+	envTestRestartFail = "SHIP_TEST_RESTART_FAIL"
+)
+
+func TestRestart(t *testing.T) {
+	if os.Getenv(EnvListenFDs) != "" {
+		testRestartChild(t)
+		return
+	}
+
+	var addrs []string
+	var lns []net.Listener
+	for i := 0; i < 3; i++ {
+		ln, err := Listen("127.0.0.1:0")
+		if !assert.NoError(t, err) {
+			return
+		}
+		lns = append(lns, ln)
+		addrs = append(addrs, ln.Addr().String())
+	}
+
+	s1 := New(SetSignal([]os.Signal{}), SetRestartSignal(syscall.SIGUSR2),
+		SetLogger(NewNoLevelLogger(ioutil.Discard)))
+	s2 := s1.Clone("clone")
+	s3 := New(SetSignal([]os.Signal{}), SetLogger(NewNoLevelLogger(ioutil.Discard)))
+	s3.Link(s1)
+	assert.Nil(t, s2.rsignals)
+
+	for i, s := range []*Ship{s1, s2, s3} {
+		name := fmt.Sprintf("parent%d", i+1)
+		s.R("/").GET(func(ctx *Context) error { return ctx.String(http.StatusOK, name) })
+		go s.Serve(lns[i])
+		for j := 0; j < 100 && !s.IsReady(); j++ {
+			time.Sleep(time.Millisecond * 10)
+		}
+	}
+
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	get := func(addr, path string) string {
+		resp, err := client.Get("http://" + addr + path)
+		if err != nil {
+			return err.Error()
+		}
+		defer resp.Body.Close()
+		data, _ := ioutil.ReadAll(resp.Body)
+		return string(data)
+	}
+	assert.Equal(t, "parent1", get(addrs[0], "/"))
+	assert.Equal(t, "parent2", get(addrs[1], "/"))
+	assert.Equal(t, "parent3", get(addrs[2], "/"))
+
+	// Only run the current test in the child process, and discard its output.
+	restart := func(env string) error {
+		args, stdout := os.Args, os.Stdout
+		os.Args = []string{os.Args[0], "-test.run=^TestRestart$"}
+		os.Stdout, _ = os.Open(os.DevNull)
+		os.Setenv(env, strings.Join(addrs[:2], ","))
+		defer func() {
+			os.Unsetenv(env)
+			os.Stdout.Close()
+			os.Args, os.Stdout = args, stdout
+		}()
+		return s1.Restart()
+	}
+
+	// The child process exits before being ready.
+	assert.Error(t, restart(envTestRestartFail))
+	assert.Equal(t, "parent1", get(addrs[0], "/"))
+	assert.Equal(t, "parent3", get(addrs[2], "/"))
+
+	if !assert.NoError(t, restart(envTestRestartAddr)) {
+		return
+	}
+	s1.Wait()
+	s2.Wait()
+	s3.Wait()
+
+	// The child process has been ready, and the third listener
+	// is not reused by the child process, so it has been closed.
+	assert.Equal(t, "child", get(addrs[0], "/"))
+	assert.Equal(t, "child", get(addrs[1], "/"))
+	assert.NotEqual(t, "parent3", get(addrs[2], "/"))
+	get(addrs[0], "/exit")
+}
+
+func testRestartChild(t *testing.T) {
+	if os.Getenv(envTestRestartFail) != "" {
+		os.Exit(1)
+	}
+
+	timer := time.AfterFunc(time.Second*10, func() { os.Exit(1) })
+	defer timer.Stop()
+
+	inheritedListenerTimeout = time.Millisecond * 100
+	inherited.once.Do(loadInheritedListeners)
+	if len(inherited.lns) != 3 {
+		t.Fatalf("expect 3 inherited listeners, but got %d", len(inherited.lns))
+	}
+
+	s := New(SetSignal([]os.Signal{}), SetLogger(NewNoLevelLogger(ioutil.Discard)))
+	s.R("/").GET(func(ctx *Context) error { return ctx.String(http.StatusOK, "child") })
+	s.R("/exit").GET(func(ctx *Context) error {
+		go s.shutdown()
+		return nil
+	})
+
+	var lns []net.Listener
+	for _, addr := range strings.Split(os.Getenv(envTestRestartAddr), ",") {
+		ln, err := Listen(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lns = append(lns, ln)
+	}
+	if len(inherited.lns) != 1 {
+		t.Fatal("the inherited listeners are not reused")
+	}
+
+	s.Serve(lns...)
+	s.Wait()
+}
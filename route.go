@@ -261,9 +261,15 @@ func (r *Route) addRoute(name, path string, handler Handler, methods ...string)
 	}
 
 	for i := middlewaresLen - 1; i >= 0; i-- {
-		handler = middlewares[i](handler)
+		if r.ship.debug {
+			handler = debugMiddleware(middlewares[i], handler)
+		} else {
+			handler = middlewares[i](handler)
+		}
 	}
 
+	handler = recordRoute(name, path, handler)
+
 	for i := range methods {
 		n := r.router.Add(name, path, strings.ToUpper(methods[i]), handler)
 		r.ship.setURLParamNum(n)
@@ -515,3 +521,13 @@ type notDirFile struct {
 func (f notDirFile) Readdir(count int) ([]os.FileInfo, error) {
 	return nil, nil
 }
+
+// recordRoute records the name and the path of the matched route.
+func recordRoute(name, path string, next Handler) Handler {
+	return func(ctx *Context) error {
+		ctx.routeName = name
+		ctx.routePath = path
+		ctx.logger = nil // Rebuild the logger with the route name.
+		return next(ctx)
+	}
+}
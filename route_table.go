@@ -0,0 +1,61 @@
+// Copyright 2019 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ship
+
+// RouteTable is a route table based on a new Router, which is used to build
+// the routes offline and not affect the running router of Ship.
+//
+// The routes of the route table inherit the prefix and the global middlewares
+// of Ship. After building, you can call Ship.SwapRouter(table.Router())
+// to swap it into Ship.
+type RouteTable struct {
+	ship   *Ship
+	router Router
+}
+
+// Router returns the inner Router of the route table.
+func (t *RouteTable) Router() Router {
+	return t.router
+}
+
+// Group returns a new sub-group based on the route table.
+func (t *RouteTable) Group(prefix string, middlewares ...Middleware) *Group {
+	ms := make([]Middleware, 0, len(t.ship.middlewares)+len(middlewares))
+	ms = append(ms, t.ship.middlewares...)
+	ms = append(ms, middlewares...)
+	return newGroup(t.ship, t.router, t.ship.prefix, prefix, ms...)
+}
+
+// GroupWithoutMiddleware is the same as Group, but not inherit the middlewares of Ship.
+func (t *RouteTable) GroupWithoutMiddleware(prefix string, middlewares ...Middleware) *Group {
+	ms := make([]Middleware, 0, len(middlewares))
+	ms = append(ms, middlewares...)
+	return newGroup(t.ship, t.router, t.ship.prefix, prefix, ms...)
+}
+
+// RouteWithoutMiddleware is the same as Route, but not inherit the middlewares of Ship.
+func (t *RouteTable) RouteWithoutMiddleware(path string) *Route {
+	return newRoute(t.ship, t.router, t.ship.prefix, path)
+}
+
+// Route returns a new route based on the route table.
+func (t *RouteTable) Route(path string) *Route {
+	return newRoute(t.ship, t.router, t.ship.prefix, path, t.ship.middlewares...)
+}
+
+// R is short for RouteTable#Route(path).
+func (t *RouteTable) R(path string) *Route {
+	return t.Route(path)
+}
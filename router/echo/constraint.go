@@ -0,0 +1,120 @@
+// Copyright 2019 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package echo
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+)
+
+var (
+	constraintLock sync.RWMutex
+	constraints    = map[string]func(string) bool{
+		"int":   func(s string) bool { _, err := strconv.ParseInt(s, 10, 64); return err == nil },
+		"uint":  func(s string) bool { _, err := strconv.ParseUint(s, 10, 64); return err == nil },
+		"float": func(s string) bool { _, err := strconv.ParseFloat(s, 64); return err == nil },
+		"alpha": regexp.MustCompile(`^[a-zA-Z]+$`).MatchString,
+		"alnum": regexp.MustCompile(`^[a-zA-Z0-9]+$`).MatchString,
+		"hex":   regexp.MustCompile(`^[a-fA-F0-9]+$`).MatchString,
+	}
+)
+
+// AddConstraint registers a named constraint for the path parameter,
+// which can be used like `/path/:param<name>`.
+//
+// The builtin constraints are "int", "uint", "float", "alpha", "alnum"
+// and "hex". Any other constraint is regarded as a regular expression,
+// which must match the whole parameter value.
+func AddConstraint(name string, match func(value string) bool) {
+	if name == "" {
+		panic(errors.New("the constraint name is empty"))
+	} else if match == nil {
+		panic(errors.New("the constraint match function is nil"))
+	}
+
+	constraintLock.Lock()
+	constraints[name] = match
+	constraintLock.Unlock()
+}
+
+// constraint is used to check whether the value of the path parameter
+// is valid.
+type constraint struct {
+	pattern string
+	match   func(string) bool
+}
+
+func newConstraint(pattern string) *constraint {
+	constraintLock.RLock()
+	match := constraints[pattern]
+	constraintLock.RUnlock()
+
+	if match == nil {
+		re, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			panic(fmt.Errorf("invalid constraint '%s': %s", pattern, err))
+		}
+		match = re.MatchString
+	}
+	return &constraint{pattern: pattern, match: match}
+}
+
+func (c *constraint) Match(value string) bool {
+	return c == nil || c.match(value)
+}
+
+func (c *constraint) Pattern() string {
+	if c == nil {
+		return ""
+	}
+	return c.pattern
+}
+
+// parseParam parses the parameter starting from path[start], which is
+// the first character following ':', and returns the parameter name,
+// the constraint and the end index of the parameter in the path.
+//
+// The parameter is in the format of "name" or "name<constraint>".
+func parseParam(path string, start int) (name string, c *constraint, end int) {
+	end = start
+	for l := len(path); end < l && path[end] != '/'; end++ {
+		if path[end] != '<' {
+			continue
+		}
+
+		name = path[start:end]
+		depth := 0
+		for i := end; i < l; i++ {
+			switch path[i] {
+			case '<':
+				depth++
+			case '>':
+				if depth--; depth == 0 {
+					if i == end+1 {
+						panic(fmt.Errorf("the constraint of the parameter '%s' is empty", name))
+					} else if i+1 < l && path[i+1] != '/' {
+						panic(fmt.Errorf("the parameter '%s' must end with '>'", name))
+					}
+					return name, newConstraint(path[end+1 : i]), i + 1
+				}
+			}
+		}
+		panic(fmt.Errorf("the constraint of the parameter '%s' is not closed", name))
+	}
+	return path[start:end], nil, end
+}
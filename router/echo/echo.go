@@ -22,6 +22,17 @@
 // SOFTWARE.
 
 // Package echo supplies a Router implementation based on github.com/labstack/echo.
+//
+// Besides the static, the parameter and the wildcard path, the parameter
+// supports the constraint, which is appended to the parameter name and
+// surrounded by "<" and ">". For example,
+//
+//     /users/:id<int>
+//     /files/:name<[a-z0-9-]+>
+//
+// The constraint is checked when finding the route. If the value does not
+// match the constraint, it will try the sibling routes and return nil
+// if no route matches.
 package echo
 
 import (
@@ -30,6 +41,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
 	"strings"
 
 	"github.com/xgfone/ship/utils"
@@ -44,6 +56,8 @@ type (
 		Method string `json:"method"`
 		Path   string `json:"path"`
 		Name   string `json:"name"`
+
+		constraints []*constraint
 	}
 	// Router is the registry of all registered routes for an `Echo` instance
 	// for request matching and URL path parameter parsing.
@@ -63,6 +77,7 @@ type (
 		children      children
 		ppath         string
 		pnames        []string
+		constraint    *constraint
 		methodHandler *methodHandler
 	}
 	kind          uint8
@@ -78,6 +93,10 @@ type (
 		put      interface{}
 		trace    interface{}
 		propfind interface{}
+
+		// others stores the handlers of the other methods, such as
+		// "MKCOL", "LOCK", "REPORT", "PURGE" or the custom methods.
+		others map[string]interface{}
 	}
 )
 
@@ -132,13 +151,15 @@ func (r *Router) URL(name string, params ...interface{}) string {
 	n := 0
 	for i, l := 0, len(route.Path); i < l; i++ {
 		if route.Path[i] == ':' && n < ln {
-			for ; i < l && route.Path[i] != '/'; i++ {
+			_, _, i = parseParam(route.Path, i+1)
+			s, err := utils.ToString(params[n])
+			if err != nil {
+				s = fmt.Sprintf("%v", params[n])
 			}
-			if s, err := utils.ToString(params[n]); err == nil {
-				uri.WriteString(s)
-			} else {
-				fmt.Fprintf(uri, "%v", params[n])
+			if !route.constraints[n].Match(s) {
+				return ""
 			}
+			uri.WriteString(s)
 			n++
 		}
 		if i < l {
@@ -159,6 +180,7 @@ func (r *Router) Add(name, path string, method string, handler interface{}) int
 	}
 
 	_route := &route{Name: name, Method: method, Path: path}
+	_, _route.constraints = stripPath(path)
 	if len(name) > 0 {
 		if _r, ok := r.routes[name]; ok && _r.Path != path {
 			panic(fmt.Errorf("the url name '%s' has been registered for the path '%s'",
@@ -171,6 +193,94 @@ func (r *Router) Add(name, path string, method string, handler interface{}) int
 	return r.add(path, method, handler)
 }
 
+// Remove implements github.com/xgfone/ship:Router#Remove, which will remove
+// the route by name, path and method.
+//
+// If path is empty, it is the path of the route named name. If method is
+// empty, all the routes of the path will be removed.
+func (r *Router) Remove(name, path, method string) {
+	if path == "" {
+		route := r.routes[name]
+		if route == nil {
+			return
+		}
+		path = route.Path
+	}
+
+	routes := make([]*route, 0, len(r.allroutes))
+	for _, _r := range r.allroutes {
+		if _r.Path != path || (method != "" && _r.Method != method) ||
+			(name != "" && _r.Name != name) {
+			routes = append(routes, _r)
+			continue
+		}
+
+		if _r.Name != "" && r.routes[_r.Name] == _r {
+			delete(r.routes, _r.Name)
+		}
+		if n := r.lookup(stripPath(_r.Path)); n != nil {
+			n.addHandler(_r.Method, nil)
+			n.prune()
+		}
+	}
+	r.allroutes = routes
+
+	// The url name may be shared by the routes of the different methods.
+	for _, _r := range routes {
+		if _r.Name != "" && r.routes[_r.Name] == nil {
+			r.routes[_r.Name] = _r
+		}
+	}
+}
+
+// stripPath strips the names and the constraints of the parameters
+// from the path, and returns the constraints of the parameters.
+func stripPath(path string) (string, []*constraint) {
+	if path == "" || path[0] != '/' {
+		path = "/" + path
+	}
+
+	var cs []*constraint
+	for i, l := 0, len(path); i < l; i++ {
+		switch path[i] {
+		case ':':
+			_, c, end := parseParam(path, i+1)
+			cs = append(cs, c)
+			path = path[:i+1] + path[end:]
+			l = len(path)
+		case '*':
+			return path[:i+1], cs
+		}
+	}
+	return path, cs
+}
+
+// lookup returns the node whose full path is path, which has been stripped
+// by stripPath, or nil if not found.
+func (r *Router) lookup(path string, cs []*constraint) *node {
+	cn := r.tree
+	search := path
+	for {
+		pl := len(cn.prefix)
+		if len(search) < pl || search[:pl] != cn.prefix {
+			return nil
+		} else if search = search[pl:]; search == "" {
+			return cn
+		}
+
+		if search[0] == ':' {
+			i := strings.Count(path[:len(path)-len(search)], ":")
+			cn = cn.findParamChildByPattern(cs[i].Pattern())
+		} else {
+			cn = cn.findChildWithLabel(search[0])
+		}
+
+		if cn == nil {
+			return nil
+		}
+	}
+}
+
 func (r *Router) add(path string, method string, h interface{}) int {
 	// Validate path
 	if path == "" {
@@ -180,8 +290,9 @@ func (r *Router) add(path string, method string, h interface{}) int {
 		path = "/" + path
 	}
 
-	pnames := []string{} // Param names
-	ppath := path        // Pristine path
+	pnames := []string{}  // Param names
+	cs := []*constraint{} // Param constraints
+	ppath := path         // Pristine path
 
 	for i, l := 0, len(path); i < l; i++ {
 		if path[i] == ':' {
@@ -191,25 +302,24 @@ func (r *Router) add(path string, method string, h interface{}) int {
 
 			j := i + 1
 
-			r.insert(method, path[:i], nil, skind, "", nil)
-			for ; i < l && path[i] != '/'; i++ {
-			}
-
-			if i == j {
+			r.insert(method, path[:i], nil, skind, "", nil, cs)
+			name, c, end := parseParam(path, j)
+			if name == "" {
 				panic(errors.New("':' is not followed by any argument"))
 			}
 
-			pnames = append(pnames, path[j:i])
-			path = path[:j] + path[i:]
+			pnames = append(pnames, name)
+			cs = append(cs, c)
+			path = path[:j] + path[end:]
 			i, l = j, len(path)
 
 			if i == l {
-				r.insert(method, path[:i], h, pkind, ppath, pnames)
+				r.insert(method, path[:i], h, pkind, ppath, pnames, cs)
 				return len(pnames)
 			}
-			r.insert(method, path[:i], nil, pkind, "", nil)
+			r.insert(method, path[:i], nil, pkind, "", nil, cs)
 		} else if path[i] == '*' {
-			r.insert(method, path[:i], nil, skind, "", nil)
+			r.insert(method, path[:i], nil, skind, "", nil, cs)
 
 			name := strings.TrimRight(path[i+1:], "/ ")
 			if name == "" {
@@ -217,17 +327,19 @@ func (r *Router) add(path string, method string, h interface{}) int {
 			}
 			pnames = append(pnames, name)
 
-			r.insert(method, path[:i+1], h, akind, ppath, pnames)
+			r.insert(method, path[:i+1], h, akind, ppath, pnames, cs)
 			return len(pnames)
 		}
 	}
 
-	r.insert(method, path, h, skind, ppath, pnames)
+	r.insert(method, path, h, skind, ppath, pnames, cs)
 	return len(pnames)
 }
 
+// insert inserts the path into the tree, and cs is the constraints of
+// the parameters in the path, each of which corresponds to a ':'.
 func (r *Router) insert(method, path string, h interface{}, t kind,
-	ppath string, pnames []string) {
+	ppath string, pnames []string, cs []*constraint) {
 
 	cn := r.tree // Current node as root
 	if cn == nil {
@@ -235,6 +347,12 @@ func (r *Router) insert(method, path string, h interface{}, t kind,
 	}
 	search := path
 
+	// constraintOf returns the constraint of the parameter node
+	// at the start of search.
+	constraintOf := func(search string) *constraint {
+		return cs[strings.Count(path[:len(path)-len(search)], ":")]
+	}
+
 	for {
 		sl := len(search)
 		pl := len(cn.prefix)
@@ -263,8 +381,11 @@ func (r *Router) insert(method, path string, h interface{}, t kind,
 			n := newNode(r, cn.kind, cn.prefix[l:], cn, cn.children,
 				cn.methodHandler, cn.ppath, cn.pnames)
 
+			n.constraint = cn.constraint
+
 			// Reset parent node
 			cn.kind = skind
+			cn.constraint = nil
 			cn.label = cn.prefix[0]
 			cn.prefix = cn.prefix[:l]
 			cn.children = nil
@@ -283,12 +404,20 @@ func (r *Router) insert(method, path string, h interface{}, t kind,
 			} else {
 				// Create child node
 				n = newNode(r, t, search[l:], cn, nil, new(methodHandler), ppath, pnames)
+				if search[l] == ':' {
+					n.constraint = constraintOf(search[l:])
+				}
 				n.addHandler(method, h)
 				cn.addChild(n)
 			}
 		} else if l < sl {
 			search = search[l:]
-			c := cn.findChildWithLabel(search[0])
+			var c *node
+			if search[0] == ':' {
+				c = cn.findParamChildByPattern(constraintOf(search).Pattern())
+			} else {
+				c = cn.findChildWithLabel(search[0])
+			}
 			if c != nil {
 				// Go deeper
 				cn = c
@@ -296,6 +425,9 @@ func (r *Router) insert(method, path string, h interface{}, t kind,
 			}
 			// Create child node
 			n := newNode(r, t, search, cn, nil, new(methodHandler), ppath, pnames)
+			if search[0] == ':' {
+				n.constraint = constraintOf(search)
+			}
 			n.addHandler(method, h)
 			cn.addChild(n)
 		} else {
@@ -331,6 +463,31 @@ func (n *node) addChild(c *node) {
 	n.children = append(n.children, c)
 }
 
+func (n *node) delChild(c *node) {
+	for i := range n.children {
+		if n.children[i] == c {
+			n.children = append(n.children[:i], n.children[i+1:]...)
+			return
+		}
+	}
+}
+
+// prune removes the node and its ancestors from the tree
+// if they have neither the handler nor the child.
+func (n *node) prune() {
+	for cn := n; cn.parent != nil && len(cn.children) == 0 && !cn.hasHandler(); cn = cn.parent {
+		cn.parent.delChild(cn)
+	}
+}
+
+func (n *node) hasHandler() bool {
+	h := n.methodHandler
+	return h.connect != nil || h.delete != nil || h.get != nil ||
+		h.head != nil || h.options != nil || h.patch != nil ||
+		h.post != nil || h.put != nil || h.trace != nil ||
+		h.propfind != nil || len(h.others) > 0
+}
+
 func (n *node) findChild(l byte, t kind) *node {
 	for _, c := range n.children {
 		if c.label == l && c.kind == t {
@@ -349,6 +506,36 @@ func (n *node) findChildWithLabel(l byte) *node {
 	return nil
 }
 
+// findParamChildByPattern returns the parameter child node whose constraint
+// pattern is pattern.
+func (n *node) findParamChildByPattern(pattern string) *node {
+	for _, c := range n.children {
+		if c.kind == pkind && c.constraint.Pattern() == pattern {
+			return c
+		}
+	}
+	return nil
+}
+
+// findParamChildren returns the parameter child nodes matching the value,
+// which puts the nodes with the constraint before the one without.
+func (n *node) findParamChildren(value string) (children []*node) {
+	var any *node
+	for _, c := range n.children {
+		if c.kind == pkind {
+			if c.constraint == nil {
+				any = c
+			} else if c.constraint.Match(value) {
+				children = append(children, c)
+			}
+		}
+	}
+	if any != nil {
+		children = append(children, any)
+	}
+	return
+}
+
 func (n *node) findChildByKind(t kind) *node {
 	for _, c := range n.children {
 		if c.kind == t {
@@ -381,8 +568,47 @@ func (n *node) addHandler(method string, h interface{}) {
 	case "PROPFIND":
 		n.methodHandler.propfind = h
 	default:
-		panic(errors.New("not support the method +'" + method + "'"))
+		if !isMethodToken(method) {
+			panic(errors.New("invalid method '" + method + "'"))
+		}
+		if h == nil {
+			delete(n.methodHandler.others, method)
+			return
+		}
+		if n.methodHandler.others == nil {
+			n.methodHandler.others = make(map[string]interface{}, 2)
+		}
+		n.methodHandler.others[method] = h
+	}
+}
+
+// isMethodToken reports whether the method is a valid token of RFC 7230.
+func isMethodToken(method string) bool {
+	if method == "" {
+		return false
+	}
+	for i, l := 0, len(method); i < l; i++ {
+		switch c := method[i]; {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case strings.IndexByte("!#$%&'*+-.^_`|~", c) > -1:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// appendOtherMethods appends the other methods which have the handler
+// into ms in order.
+func (n *node) appendOtherMethods(ms []string) []string {
+	start := len(ms)
+	for method, h := range n.methodHandler.others {
+		if h != nil {
+			ms = append(ms, method)
+		}
 	}
+	sort.Strings(ms[start:])
+	return ms
 }
 
 func (n *node) findHandler(method string) interface{} {
@@ -408,7 +634,7 @@ func (n *node) findHandler(method string) interface{} {
 	case "PROPFIND":
 		return n.methodHandler.propfind
 	default:
-		return nil
+		return n.methodHandler.others[method]
 	}
 }
 
@@ -417,12 +643,13 @@ func (n *node) checkMethodNotAllowed(method string) interface{} {
 		return nil
 	}
 
-	ms := make([]string, 0, len(methods))
+	ms := make([]string, 0, len(methods)+len(n.methodHandler.others))
 	for _, m := range methods {
 		if h := n.findHandler(m); h != nil {
 			ms = append(ms, m)
 		}
 	}
+	ms = n.appendOtherMethods(ms)
 
 	if len(ms) == 0 {
 		return nil
@@ -435,8 +662,8 @@ func (n *node) checkOptions(method string) interface{} {
 		return nil
 	}
 
-	ms := make([]string, 0, len(methods))
 	h := n.methodHandler
+	ms := make([]string, 0, len(methods)+len(h.others))
 	if h.connect != nil {
 		ms = append(ms, http.MethodConnect)
 	}
@@ -464,6 +691,7 @@ func (n *node) checkOptions(method string) interface{} {
 	if h.propfind != nil {
 		ms = append(ms, PROPFIND)
 	}
+	ms = n.appendOtherMethods(ms)
 
 	if len(ms) == 0 {
 		return nil
@@ -473,15 +701,31 @@ func (n *node) checkOptions(method string) interface{} {
 
 // Find implements github.com/xgfone/ship:Router#Find.
 func (r *Router) Find(method, path string, pnames, pvalues []string) (handler interface{}) {
-	cn := r.tree // Current node as root
+	handler, _ = r.find(r.tree, method, path, pnames, pvalues, 0)
+	return
+}
+
+// find finds the handler from the node cn, and reports whether the handler
+// is registered for the method, not the one to handle OPTIONS or
+// "Method Not Allowed". n is the number of the found parameters.
+//
+// If the value of the parameter matches more than one parameter node,
+// it will try them in turn until the handler of the method is found.
+func (r *Router) find(cn *node, method, search string, pnames, pvalues []string,
+	n int) (handler interface{}, ok bool) {
+	var fallback interface{} // The handler found by the tried parameter nodes
+	defer func() {
+		if handler == nil {
+			handler = fallback
+		}
+	}()
 
 	var (
-		search = path
-		child  *node  // Child node
-		n      int    // Param counter
-		nk     kind   // Next kind
-		nn     *node  // Next node
-		ns     string // Next search
+		child *node  // Child node
+		nk    kind   // Next kind
+		nn    *node  // Next node
+		ns    string // Next search
+		pe    int    // End of the param value
 	)
 
 	// Search order static > param > any
@@ -539,12 +783,30 @@ func (r *Router) Find(method, path string, pnames, pvalues []string) (handler in
 
 		// Param node
 	Param:
-		if child = cn.findChildByKind(pkind); child != nil {
+		for pe = 0; pe < len(search) && search[pe] != '/'; pe++ {
+		}
+		if children := cn.findParamChildren(search[:pe]); len(children) > 0 {
 			// Issue #378
 			if len(pvalues) == n {
 				continue
 			}
 
+			// Try the constrained nodes before the last one.
+			last := len(children) - 1
+			for _, child = range children[:last] {
+				pvalues[n] = search[:pe]
+				h, found := r.find(child, method, search[pe:], pnames, pvalues, n+1)
+				if found {
+					return h, true
+				} else if fallback == nil {
+					fallback = h
+				}
+				for i := range pnames {
+					pnames[i] = ""
+				}
+			}
+			child = children[last]
+
 			// Save next
 			if cn.prefix[len(cn.prefix)-1] == '/' { // Issue #623
 				nk = akind
@@ -553,12 +815,9 @@ func (r *Router) Find(method, path string, pnames, pvalues []string) (handler in
 			}
 
 			cn = child
-			i, l := 0, len(search)
-			for ; i < l && search[i] != '/'; i++ {
-			}
-			pvalues[n] = search[:i]
+			pvalues[n] = search[:pe]
 			n++
-			search = search[i:]
+			search = search[pe:]
 			continue
 		}
 
@@ -584,27 +843,29 @@ func (r *Router) Find(method, path string, pnames, pvalues []string) (handler in
 
 	handler = cn.findHandler(method)
 	copy(pnames, cn.pnames)
+	if ok = handler != nil; ok {
+		return
+	}
 
 	// NOTE: Slow zone...
-	if handler == nil {
-		if handler = cn.checkOptions(method); handler != nil {
-			return
-		}
-		_cn := cn
-
-		// Dig further for any, might have an empty value for *, e.g.
-		// serving a directory. Issue #207.
-		if cn = cn.findChildByKind(akind); cn == nil {
-			handler = _cn.checkMethodNotAllowed(method)
-			return
-		}
-		if handler = cn.findHandler(method); handler == nil {
-			handler = cn.checkMethodNotAllowed(method)
-		}
-		copy(pnames, cn.pnames)
-		pvalues[len(cn.pnames)-1] = ""
+	if handler = cn.checkOptions(method); handler != nil {
+		return
 	}
+	_cn := cn
 
+	// Dig further for any, might have an empty value for *, e.g.
+	// serving a directory. Issue #207.
+	if cn = cn.findChildByKind(akind); cn == nil {
+		handler = _cn.checkMethodNotAllowed(method)
+		return
+	}
+	if handler = cn.findHandler(method); handler == nil {
+		handler = cn.checkMethodNotAllowed(method)
+	} else {
+		ok = true
+	}
+	copy(pnames, cn.pnames)
+	pvalues[len(cn.pnames)-1] = ""
 	return
 }
 
@@ -619,8 +880,8 @@ func (r *Router) PrintRouterTree(w io.Writer) {
 
 func (n *node) printTree(w io.Writer, pfx string, tail bool) {
 	p := prefix(tail, pfx, "└── ", "├── ")
-	w.Write([]byte(fmt.Sprintf("%s%s, %p: type=%s, lable=%c, path=%s, parent=%p, pnames=%v\n",
-		p, n.prefix, n, kindtypes[n.kind], n.label, n.ppath, n.parent, n.pnames)))
+	w.Write([]byte(fmt.Sprintf("%s%s, %p: type=%s, lable=%c, path=%s, parent=%p, pnames=%v, constraint=%s\n",
+		p, n.prefix, n, kindtypes[n.kind], n.label, n.ppath, n.parent, n.pnames, n.constraint.Pattern())))
 
 	children := n.children
 	l := len(children)
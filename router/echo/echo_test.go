@@ -16,6 +16,7 @@ package echo_test
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/xgfone/ship"
@@ -69,3 +70,139 @@ func TestRouter(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestRouterParamConstraint(t *testing.T) {
+	router := echo.NewRouter(nil, nil)
+	router.Add("id", "/users/:id<int>", "GET", "id")
+	router.Add("name", "/users/:name<[a-z]+>", "GET", "name")
+	router.Add("any", "/users/:any", "GET", "any")
+	router.Add("file", "/files/:name<[a-z0-9-]+>/raw", "GET", "file")
+
+	pnames := make([]string, 1)
+	pvalues := make([]string, 1)
+	for path, expected := range map[string]string{
+		"/users/123":       "id",
+		"/users/abc":       "name",
+		"/users/Abc":       "any",
+		"/files/abc-1/raw": "file",
+	} {
+		if h := router.Find("GET", path, pnames, pvalues); h != expected {
+			t.Errorf("%s: expect '%s', but got '%v'", path, expected, h)
+		}
+	}
+
+	if h := router.Find("GET", "/files/ABC/raw", pnames, pvalues); h != nil {
+		t.Errorf("expect nil, but got '%v'", h)
+	}
+
+	if url := router.URL("id", 123); url != "/users/123" {
+		t.Errorf("expect '/users/123', but got '%s'", url)
+	}
+	if url := router.URL("id", "abc"); url != "" {
+		t.Errorf("expect '', but got '%s'", url)
+	}
+	if url := router.URL("file", "abc-1"); url != "/files/abc-1/raw" {
+		t.Errorf("expect '/files/abc-1/raw', but got '%s'", url)
+	}
+
+	// Backtrack to the sibling parameter node if the deeper path fails.
+	router.Add("posts", "/users/:id<int>/posts", "GET", "posts")
+	router.Add("profile", "/users/:name/profile", "GET", "profile")
+	pnames, pvalues = make([]string, 2), make([]string, 2)
+	for path, expected := range map[string]string{
+		"/users/123/posts":   "posts",
+		"/users/123/profile": "profile",
+		"/users/abc/profile": "profile",
+	} {
+		if h := router.Find("GET", path, pnames, pvalues); h != expected {
+			t.Errorf("%s: expect '%s', but got '%v'", path, expected, h)
+		} else if path == "/users/123/profile" && (pnames[0] != "name" || pvalues[0] != "123") {
+			t.Errorf("%s: unexpected parameter '%s=%s'", path, pnames[0], pvalues[0])
+		}
+	}
+	if h := router.Find("GET", "/users/abc/posts", pnames, pvalues); h != nil {
+		t.Errorf("expect nil, but got '%v'", h)
+	}
+
+	// Backtrack to the sibling parameter node if the method fails.
+	getAllow := func(methods []string) interface{} { return "allow" }
+	router = echo.NewRouter(getAllow, nil)
+	router.Add("", "/u/:id<int>", "GET", "get")
+	router.Add("", "/u/:id<int>", "POST", "post")
+	router.Add("", "/u/:x", "PUT", "put")
+	for method, expected := range map[string]string{
+		"GET":    "get",
+		"POST":   "post",
+		"PUT":    "put",
+		"DELETE": "allow",
+	} {
+		if h := router.Find(method, "/u/1", pnames, pvalues); h != expected {
+			t.Errorf("%s: expect '%s', but got '%v'", method, expected, h)
+		}
+	}
+}
+
+func TestRouterOtherMethods(t *testing.T) {
+	var allow []string
+	getAllow := func(methods []string) interface{} { allow = methods; return "allow" }
+	router := echo.NewRouter(getAllow, getAllow)
+	router.Add("", "/dav", "MKCOL", "mkcol")
+	router.Add("", "/dav", "LOCK", "lock")
+	router.Add("", "/dav", "GET", "get")
+
+	if h := router.Find("MKCOL", "/dav", nil, nil); h != "mkcol" {
+		t.Errorf("expect 'mkcol', but got '%v'", h)
+	}
+	if h := router.Find("LOCK", "/dav", nil, nil); h != "lock" {
+		t.Errorf("expect 'lock', but got '%v'", h)
+	}
+
+	if h := router.Find("PURGE", "/dav", nil, nil); h != "allow" {
+		t.Errorf("expect 'allow', but got '%v'", h)
+	} else if strings.Join(allow, ", ") != "GET, LOCK, MKCOL" {
+		t.Errorf("unexpected allowed methods: %v", allow)
+	}
+
+	if h := router.Find("OPTIONS", "/dav", nil, nil); h != "allow" {
+		t.Errorf("expect 'allow', but got '%v'", h)
+	} else if strings.Join(allow, ", ") != "GET, LOCK, MKCOL" {
+		t.Errorf("unexpected allowed methods: %v", allow)
+	}
+}
+
+func TestRouterRemove(t *testing.T) {
+	router := echo.NewRouter(nil, nil)
+	router.Add("user", "/users/:id<int>", "GET", "get")
+	router.Add("user", "/users/:id<int>", "PUT", "put")
+	router.Add("", "/users/:id<int>/posts", "GET", "posts")
+
+	pnames := make([]string, 1)
+	pvalues := make([]string, 1)
+
+	router.Remove("", "/users/:id<int>", "GET")
+	if h := router.Find("GET", "/users/1", pnames, pvalues); h != nil {
+		t.Errorf("expect nil, but got '%v'", h)
+	}
+	if h := router.Find("PUT", "/users/1", pnames, pvalues); h != "put" {
+		t.Errorf("expect 'put', but got '%v'", h)
+	}
+	if url := router.URL("user", 1); url != "/users/1" {
+		t.Errorf("expect '/users/1', but got '%s'", url)
+	}
+
+	router.Remove("user", "", "")
+	if h := router.Find("PUT", "/users/1", pnames, pvalues); h != nil {
+		t.Errorf("expect nil, but got '%v'", h)
+	}
+	if h := router.Find("GET", "/users/1/posts", pnames, pvalues); h != "posts" {
+		t.Errorf("expect 'posts', but got '%v'", h)
+	}
+	if url := router.URL("user", 1); url != "" {
+		t.Errorf("expect '', but got '%s'", url)
+	}
+
+	router.Remove("", "/users/:id<int>/posts", "")
+	router.Each(func(name, method, path string) {
+		t.Errorf("unexpected route: name=%s, method=%s, path=%s", name, method, path)
+	})
+}
@@ -51,6 +51,12 @@ func (lr *lockedRouter) Add(name string, path string, method string,
 	return
 }
 
+func (lr *lockedRouter) Remove(name string, path string, method string) {
+	lr.Lock()
+	lr.router.Remove(name, path, method)
+	lr.Unlock()
+}
+
 func (lr *lockedRouter) Find(method string, path string,
 	pnames []string, pvalues []string) (handler interface{}) {
 	lr.RLock()
@@ -15,6 +15,8 @@
 package lock
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 
@@ -42,6 +44,37 @@ func TestGithubAPI(t *testing.T) {
 	}
 }
 
+func TestLockedRouterWithShip(t *testing.T) {
+	s := ship.New(ship.SetNewRouter(func() ship.Router {
+		return LockedRouter(echo.NewRouter(
+			ship.ToRouterHandler(ship.MethodNotAllowedHandler()),
+			ship.ToRouterHandler(ship.OptionsHandler()),
+		))
+	}))
+	s.Route("/path").GET(ship.OkHandler())
+
+	for method, code := range map[string]int{
+		http.MethodGet:     http.StatusOK,
+		http.MethodPost:    http.StatusMethodNotAllowed,
+		http.MethodOptions: http.StatusOK,
+	} {
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest(method, "/path", nil))
+		if rec.Code != code {
+			t.Errorf("%s: expect %d, but got %d", method, code, rec.Code)
+		} else if method != http.MethodGet && rec.Header().Get("Allow") == "" {
+			t.Errorf("%s: missing the header Allow", method)
+		}
+	}
+
+	s.Router().Remove("", "/path", http.MethodGet)
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/path", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expect 404, but got %d", rec.Code)
+	}
+}
+
 type route struct {
 	Method string
 	Path   string
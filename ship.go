@@ -17,6 +17,8 @@ package ship
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"log"
 	"net"
@@ -24,8 +26,11 @@ import (
 	"net/url"
 	"os"
 	"os/signal"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
+	"time"
 
 	"github.com/xgfone/ship/router/echo"
 	"github.com/xgfone/ship/utils"
@@ -58,10 +63,27 @@ type Router interface {
 	// of the parameters should be stored `pnames` and `pvalues` respectively.
 	Find(method string, path string, pnames []string, pvalues []string) (handler interface{})
 
+	// Remove the route by name, path and method.
+	//
+	// If path is "", it is the path of the route named name.
+	// If method is "", it should remove the routes of all the methods
+	// for the path. If the route does not exist, it should do nothing.
+	//
+	// Notice: the default router is not thread-safe, so the routes should
+	// not be removed while the ship is serving unless the router is wrapped
+	// by "github.com/xgfone/ship/router/lock". See Ship.Router.
+	Remove(name string, path string, method string)
+
 	// Traverse each route.
 	Each(func(name string, method string, path string))
 }
 
+// routerT is used to store the Router into atomic.Value,
+// which requires the consistent concrete type.
+type routerT struct {
+	Router
+}
+
 // Resetter is an Reset interface.
 type Resetter interface {
 	Reset()
@@ -98,11 +120,14 @@ type Ship struct {
 	debug  bool
 	prefix string
 
-	logger   Logger
-	binder   Binder
-	session  Session
-	renderer Renderer
-	signals  []os.Signal
+	logger    Logger
+	binder    Binder
+	session   Session
+	renderer  Renderer
+	validator Validator
+	signals   []os.Signal
+	rsignals  []os.Signal
+	rtimeout  time.Duration
 
 	bufferSize            int
 	ctxDataSize           int
@@ -123,29 +148,48 @@ type Ship struct {
 	handleError func(*Context, error)
 	ctxHandler  func(*Context, ...interface{}) error
 	bindQuery   func(interface{}, url.Values) error
+	bindLimits  bindLimits
 
 	/// Inner settings
 	ctxpool sync.Pool
 	bufpool utils.BufferPool
 
-	maxNum int
-	router Router
+	maxNum int32
+	router atomic.Value // routerT
 
 	handler        Handler
 	premiddlewares []Middleware
 	middlewares    []Middleware
 
 	links  []*Ship
+	clones []*Ship
+	parent *Ship // The ship cloned from
 	vhosts map[string]*Ship
 
+	vhostPatterns []*vhostPattern
+	defaultVHost  *Ship
+
 	server *http.Server
+	lns    []net.Listener
 	stopfs []*stopT
-	once1  sync.Once // For shutdown
+	hooks  [shutdownPhaseNum][]func(context.Context) error
+	ready  int32
+	closed int32     // For shutdown
 	once2  sync.Once // For stop
 	done   chan struct{}
 	lock   sync.RWMutex
 
-	connState func(net.Conn, http.ConnState)
+	shutdownDelay       time.Duration
+	shutdownTimeout     time.Duration
+	shutdownHookTimeout time.Duration
+
+	tlsCert           *tlsCertFile
+	tlsConfig         *tls.Config
+	tlsReloadSignals  []os.Signal
+	tlsReloadInterval time.Duration
+
+	connState  func(net.Conn, http.ConnState)
+	configures []func(*http.Server) error
 }
 
 // New returns a new Ship.
@@ -168,25 +212,29 @@ func New(options ...Option) *Ship {
 	mr.Add("jsonpretty", JSONPrettyRenderer("    "))
 	mr.Add("xml", XMLRenderer())
 	mr.Add("xmlpretty", XMLPrettyRenderer("    "))
+	mr.AddMediaType(MIMEApplicationJSON, "json")
+	mr.AddMediaType(MIMEApplicationXML, "xml")
+	mr.AddMediaType(MIMETextXML, "xml")
 	s.renderer = mr
 
 	s.bufferSize = 2048
 	s.middlewareMaxNum = 256
+	s.bindLimits = defaultBindLimits
+	s.shutdownTimeout = 30 * time.Second
+	s.shutdownHookTimeout = 10 * time.Second
+	s.rtimeout = 30 * time.Second
 	s.defaultMethodMapping = defaultMethodMapping
 
 	s.notFoundHandler = NotFoundHandler()
 
 	s.handleError = s.handleErrorDefault
-	s.bindQuery = func(v interface{}, d url.Values) error {
-		return BindURLValues(v, d, "query")
-	}
 	s.newRouter = s.defaultNewRouter
 	s.isDefaultRouter = true
 
 	/// Initialize the inner variables.
 	s.ctxpool.New = func() interface{} { return s.NewContext(nil, nil) }
 	s.bufpool = utils.NewBufferPool(s.bufferSize)
-	s.router = s.newRouter()
+	s.setRouter(s.newRouter())
 	s.handler = s.handleRequestRoute
 	s.vhosts = make(map[string]*Ship)
 	s.done = make(chan struct{}, 1)
@@ -197,10 +245,10 @@ func New(options ...Option) *Ship {
 func (s *Ship) defaultNewRouter() Router {
 	var handleMethodNotAllowed, handleOptions func([]string) interface{}
 	if s.methodNotAllowedHandler != nil {
-		handleMethodNotAllowed = toRouterHandler(s.methodNotAllowedHandler)
+		handleMethodNotAllowed = ToRouterHandler(s.methodNotAllowedHandler)
 	}
 	if s.optionsHandler != nil {
-		handleOptions = toRouterHandler(s.optionsHandler)
+		handleOptions = ToRouterHandler(s.optionsHandler)
 	}
 	return echo.NewRouter(handleMethodNotAllowed, handleOptions)
 }
@@ -212,11 +260,14 @@ func (s *Ship) clone() *Ship {
 		debug:  s.debug,
 		prefix: s.prefix,
 
-		logger:   s.logger,
-		binder:   s.binder,
-		session:  s.session,
-		renderer: s.renderer,
-		signals:  s.signals,
+		logger:    s.logger,
+		binder:    s.binder,
+		session:   s.session,
+		renderer:  s.renderer,
+		validator: s.validator,
+		signals:   s.signals,
+		rsignals:  s.rsignals,
+		rtimeout:  s.rtimeout,
 
 		bufferSize:            s.bufferSize,
 		ctxDataSize:           s.ctxDataSize,
@@ -230,30 +281,56 @@ func (s *Ship) clone() *Ship {
 
 		isDefaultRouter: s.isDefaultRouter,
 
+		shutdownDelay:       s.shutdownDelay,
+		shutdownTimeout:     s.shutdownTimeout,
+		shutdownHookTimeout: s.shutdownHookTimeout,
+
+		tlsConfig:         s.tlsConfig,
+		tlsReloadSignals:  s.tlsReloadSignals,
+		tlsReloadInterval: s.tlsReloadInterval,
+		connState:         s.connState,
+
 		newRouter:   s.newRouter,
 		newCtxData:  s.newCtxData,
 		handleError: s.handleError,
 		ctxHandler:  s.ctxHandler,
 		bindQuery:   s.bindQuery,
+		bindLimits:  s.bindLimits,
+		configures:  append([]func(*http.Server) error(nil), s.configures...),
 
 		// Inner variables
 		bufpool: utils.NewBufferPool(s.bufferSize),
-		router:  s.newRouter(),
 		handler: s.handleRequestRoute,
 		vhosts:  make(map[string]*Ship),
 		done:    make(chan struct{}, 1),
 	}
 
+	newShip.setRouter(newShip.newRouter())
 	newShip.ctxpool.New = func() interface{} { return newShip.NewContext(nil, nil) }
 	return &newShip
 }
 
 func (s *Ship) setURLParamNum(num int) {
-	if num > s.maxNum {
-		s.maxNum = num
+	for {
+		old := atomic.LoadInt32(&s.maxNum)
+		if int32(num) <= old || atomic.CompareAndSwapInt32(&s.maxNum, old, int32(num)) {
+			return
+		}
 	}
 }
 
+func (s *Ship) getURLParamNum() int {
+	return int(atomic.LoadInt32(&s.maxNum))
+}
+
+func (s *Ship) setRouter(router Router) {
+	s.router.Store(routerT{router})
+}
+
+func (s *Ship) getRouter() Router {
+	return s.router.Load().(routerT).Router
+}
+
 // Configure configures the Ship.
 //
 // Notice: the method must be called before starting the http server.
@@ -271,17 +348,29 @@ func (s *Ship) Configure(options ...Option) *Ship {
 	return s
 }
 
-// Clone returns a new Ship router with a new name by the current configuration.
+// Clone returns a new Ship router with a new name by the current configuration,
+// including the TLS configuration and the certificate files, which are loaded
+// and reloaded by the new router itself.
 //
-// Notice: the new router will disable the signals and register the shutdown
-// function into the parent Ship router.
+// Notice: the new router will disable the signals and the restart signals,
+// and register the shutdown function into the parent Ship router. When the
+// parent is restarted, the new router is also restarted.
 func (s *Ship) Clone(name ...string) *Ship {
 	newShip := s.clone()
 	newShip.signals = []os.Signal{}
+	newShip.rsignals = nil
+	newShip.parent = s
+	if s.tlsCert != nil {
+		newShip.tlsCert = newTLSCertFile(s.tlsCert.certFile, s.tlsCert.keyFile)
+	}
 	if len(name) > 0 && name[0] != "" {
 		newShip.name = name[0]
 	}
 	s.RegisterOnShutdown(newShip.shutdown)
+
+	s.lock.Lock()
+	s.clones = append(s.clones, newShip)
+	s.lock.Unlock()
 	return newShip
 }
 
@@ -289,23 +378,69 @@ func (s *Ship) Clone(name ...string) *Ship {
 //
 // For the different virtual host, you can register the same route.
 //
+// host supports the formats as follow:
+//
+//     api.example.com            // Match the host with any port.
+//     api.example.com:8080       // Only match the host with the port 8080.
+//     *.example.com              // Match any subdomain of example.com.
+//     {tenant}.example.com       // Match and capture the subdomain as tenant.
+//
+// The exact host takes precedence over the pattern, and the patterns are
+// matched in turn by the order registered. The captures can be got by
+// Context.HostParam(name) in the handler.
+//
 // Notice: the new virtual host won't inherit anything except the configuration.
 func (s *Ship) VHost(host string) *Ship {
 	if s.vhosts == nil {
 		panic(fmt.Errorf("the virtual host cannot create the virtual host"))
 	}
-	if s.vhosts[host] != nil {
+	if s.getVHost(host) != nil {
 		panic(fmt.Errorf("the virtual host '%s' has been added", host))
 	}
 
 	vhost := s.clone()
 	vhost.vhosts = nil
-	s.vhosts[host] = vhost
+	if host = strings.ToLower(host); isVHostPattern(host) {
+		s.vhostPatterns = append(s.vhostPatterns, newVHostPattern(host, vhost))
+	} else {
+		s.vhosts[host] = vhost
+	}
 	return vhost
 }
 
+// SetDefaultVHost sets the virtual host registered by VHost(host) as the
+// default, which will handle the request when no virtual host matches.
+//
+// If host is "", the default virtual host will be reset to the current ship.
+func (s *Ship) SetDefaultVHost(host string) *Ship {
+	if s.vhosts == nil {
+		panic(fmt.Errorf("the virtual host cannot set the default virtual host"))
+	}
+
+	if host == "" {
+		s.defaultVHost = nil
+	} else if s.defaultVHost = s.getVHost(host); s.defaultVHost == nil {
+		panic(fmt.Errorf("no virtual host '%s'", host))
+	}
+	return s
+}
+
+func (s *Ship) getVHost(host string) *Ship {
+	host = strings.ToLower(host)
+	if vhost := s.vhosts[host]; vhost != nil {
+		return vhost
+	}
+	for _, p := range s.vhostPatterns {
+		if p.host == host {
+			return p.vhost
+		}
+	}
+	return nil
+}
+
 // Link links other to the current router, that's, only if either of the two
-// routers is shutdown, another is also shutdown.
+// routers is shutdown, another is also shutdown. And they are also restarted
+// together by Restart.
 //
 // Return the current router.
 func (s *Ship) Link(other *Ship) *Ship {
@@ -323,13 +458,18 @@ func (s *Ship) Link(other *Ship) *Ship {
 
 // NewContext news and returns a Context.
 func (s *Ship) NewContext(r *http.Request, w http.ResponseWriter) *Context {
-	return newContext(s, r, w, s.maxNum)
+	return newContext(s, r, w, s.getURLParamNum())
 }
 
 // AcquireContext gets a Context from the pool.
 func (s *Ship) AcquireContext(r *http.Request, w http.ResponseWriter) *Context {
 	c := s.ctxpool.Get().(*Context)
 	c.setReqResp(r, w)
+	if n := s.getURLParamNum(); len(c.pnames) < n {
+		// The routes with more parameters have been added at runtime.
+		c.pnames = make([]string, n)
+		c.pvalues = make([]string, n)
+	}
 	return c
 }
 
@@ -376,6 +516,13 @@ func (s *Ship) Binder() Binder {
 	return s.binder
 }
 
+// Validator returns the inner Validator.
+//
+// Return nil if no Validator is set.
+func (s *Ship) Validator() Validator {
+	return s.validator
+}
+
 // MuxBinder check whether the inner Binder is MuxBinder.
 //
 // If yes, return it as "*MuxBinder"; or return nil.
@@ -393,7 +540,11 @@ func (s *Ship) Pre(middlewares ...Middleware) *Ship {
 
 	handler := s.handleRequestRoute
 	for i := len(s.premiddlewares) - 1; i >= 0; i-- {
-		handler = s.premiddlewares[i](handler)
+		if s.debug {
+			handler = debugMiddleware(s.premiddlewares[i], handler)
+		} else {
+			handler = s.premiddlewares[i](handler)
+		}
 	}
 	s.handler = handler
 
@@ -412,26 +563,26 @@ func (s *Ship) Group(prefix string, middlewares ...Middleware) *Group {
 	ms := make([]Middleware, 0, len(s.middlewares)+len(middlewares))
 	ms = append(ms, s.middlewares...)
 	ms = append(ms, middlewares...)
-	return newGroup(s, s.router, s.prefix, prefix, ms...)
+	return newGroup(s, s.getRouter(), s.prefix, prefix, ms...)
 }
 
 // GroupWithoutMiddleware is the same as Group, but not inherit the middlewares of Ship.
 func (s *Ship) GroupWithoutMiddleware(prefix string, middlewares ...Middleware) *Group {
 	ms := make([]Middleware, 0, len(middlewares))
 	ms = append(ms, middlewares...)
-	return newGroup(s, s.router, s.prefix, prefix, ms...)
+	return newGroup(s, s.getRouter(), s.prefix, prefix, ms...)
 }
 
 // RouteWithoutMiddleware is the same as Route, but not inherit the middlewares of Ship.
 func (s *Ship) RouteWithoutMiddleware(path string) *Route {
-	return newRoute(s, s.router, s.prefix, path)
+	return newRoute(s, s.getRouter(), s.prefix, path)
 }
 
 // Route returns a new route, then you can customize and register it.
 //
 // You must call Route.Method() or its short method.
 func (s *Ship) Route(path string) *Route {
-	return newRoute(s, s.router, s.prefix, path, s.middlewares...)
+	return newRoute(s, s.getRouter(), s.prefix, path, s.middlewares...)
 }
 
 // R is short for Ship#Route(path).
@@ -440,29 +591,75 @@ func (s *Ship) R(path string) *Route {
 }
 
 // Router returns the inner Router.
+//
+// Notice: the default router is not thread-safe. In order to add or remove
+// the routes while the ship is serving, you should wrap it by the locked
+// router, such as
+//
+//     import "github.com/xgfone/ship/router/lock"
+//     import "github.com/xgfone/ship/router/echo"
+//
+//     router := ship.New(ship.SetNewRouter(func() ship.Router {
+//         return lock.LockedRouter(echo.NewRouter(
+//             ship.ToRouterHandler(ship.MethodNotAllowedHandler()),
+//             ship.ToRouterHandler(ship.OptionsHandler()),
+//         ))
+//     }))
+//     router.Router().Remove("", "/path", "GET")
+//
+// or build the new routes by NewRouteTable and swap them by SwapRouter.
 func (s *Ship) Router() Router {
-	return s.router
+	return s.getRouter()
+}
+
+// NewRouteTable returns a new route table with a new Router, which is used
+// to build the routes offline, then swap it by SwapRouter. For example,
+//
+//     table := router.NewRouteTable()
+//     table.Route("/path1").GET(handler1)
+//     table.Route("/path2").GET(handler2)
+//     router.SwapRouter(table.Router())
+//
+func (s *Ship) NewRouteTable() *RouteTable {
+	return &RouteTable{ship: s, router: s.newRouter()}
+}
+
+// SwapRouter replaces the inner Router with router atomically,
+// and returns the old one.
+//
+// The requests which have been being handled will go on using the old router,
+// and the new requests will use the new router.
+func (s *Ship) SwapRouter(router Router) (old Router) {
+	if router == nil {
+		panic(errors.New("the router is nil"))
+	}
+
+	s.lock.Lock()
+	old = s.getRouter()
+	s.setRouter(router)
+	s.lock.Unlock()
+	return
 }
 
 // URL generates an URL from route name and provided parameters.
 func (s *Ship) URL(name string, params ...interface{}) string {
-	return s.router.URL(name, params...)
+	return s.getRouter().URL(name, params...)
 }
 
 // Traverse traverses the registered route.
 func (s *Ship) Traverse(f func(name string, method string, path string)) {
-	s.router.Each(f)
+	s.getRouter().Each(f)
 }
 
 // ServeHTTP implements the interface http.Handler.
 func (s *Ship) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	if s.vhosts != nil {
-		if vhost := s.vhosts[r.Host]; vhost != nil {
-			vhost.serveHTTP(w, r)
+	if len(s.vhosts) > 0 || len(s.vhostPatterns) > 0 || s.defaultVHost != nil {
+		if vhost, names, values := s.findVHost(r.Host); vhost != nil {
+			vhost.serveHTTP(w, r, names, values)
 			return
 		}
 	}
-	s.serveHTTP(w, r)
+	s.serveHTTP(w, r, nil, nil)
 }
 
 func (s *Ship) handleRequestRoute(c *Context) error {
@@ -472,18 +669,32 @@ func (s *Ship) handleRequestRoute(c *Context) error {
 	return c.NotFoundHandler()(c)
 }
 
-func (s *Ship) serveHTTP(w http.ResponseWriter, r *http.Request) {
+func (s *Ship) serveHTTP(w http.ResponseWriter, r *http.Request, hnames, hvalues []string) {
 	ctx := s.AcquireContext(r, w)
-	ctx.router = s.router
+	ctx.router = s.getRouter()
+	ctx.hnames = hnames
+	ctx.hvalues = hvalues
 	err := s.handler(ctx)
 
 	if err == nil {
 		err = ctx.Err
 	}
+
+	var abort bool
 	if err != nil {
+		// If the handler panics after the response has been written partly,
+		// the client cannot know that the response is incomplete. So we
+		// close the connection by aborting the handler.
+		if ctx.IsResponded() {
+			_, abort = AsPanicError(err)
+		}
 		s.handleError(ctx, err)
 	}
 	s.ReleaseContext(ctx)
+
+	if abort {
+		panic(http.ErrAbortHandler)
+	}
 }
 
 func (s *Ship) handleErrorDefault(ctx *Context, err error) {
@@ -492,9 +703,10 @@ func (s *Ship) handleErrorDefault(ctx *Context, err error) {
 		return
 	}
 
-	if !ctx.IsResponded() {
-		switch e := err.(type) {
-		case HTTPError:
+	if s.debug && !ctx.IsResponded() {
+		handleErrorDebug(ctx, err)
+	} else if !ctx.IsResponded() {
+		if e, ok := toHTTPError(err); ok {
 			if e.Code < 500 {
 				if e.Msg == "" {
 					if e.Err == nil {
@@ -510,19 +722,38 @@ func (s *Ship) handleErrorDefault(ctx *Context, err error) {
 				return
 			}
 			ctx.Blob(e.Code, e.CT, []byte(e.Msg))
-		default:
+		} else {
 			ctx.NoContent(http.StatusInternalServerError)
-			goto END
 		}
 	}
 
-END:
-	if !s.disableErrorLog {
+	if s.disableErrorLog {
+		return
+	}
+
+	pe, isPanic := AsPanicError(err)
+	if logger, ok := ctx.Logger().(FieldLogger); ok {
+		fields := []interface{}{"status", ErrorStatusCode(err)}
+		if isPanic {
+			fields = append(fields, "stack", pe.Stack)
+		}
+		logger.With(fields...).Error("%s", err)
+	} else if isPanic {
+		s.logger.Error("panic: %s\n%s", err, pe.Stack)
+	} else {
 		s.logger.Error("%s", err)
 	}
 }
 
-// Shutdown stops the HTTP server.
+// Shutdown stops the HTTP server gracefully, which will mark the ship
+// not ready, run the shutdown hooks and drain the active connections.
+// See RegisterShutdownHook.
+//
+// If ctx is done or the timeout set by SetShutdownTimeout is exceeded
+// before the connections are drained, they will be closed forcibly
+// and the error will be returned.
+//
+// Notice: only the first call takes effect, and the later calls return nil.
 func (s *Ship) Shutdown(ctx context.Context) error {
 	s.lock.RLock()
 	server := s.server
@@ -531,7 +762,10 @@ func (s *Ship) Shutdown(ctx context.Context) error {
 	if server == nil {
 		return fmt.Errorf("the server has not been started")
 	}
-	return server.Shutdown(ctx)
+	if !atomic.CompareAndSwapInt32(&s.closed, 0, 1) {
+		return nil
+	}
+	return s.shutdownServer(ctx, server)
 }
 
 // RegisterOnShutdown registers some functions to run when the http server is
@@ -554,26 +788,57 @@ func (s *Ship) SetConnStateHandler(h func(net.Conn, http.ConnState)) *Ship {
 	return s
 }
 
-// Start starts a HTTP server with addr.
+// Start starts a HTTP server with addr, which supports the formats
+// of the function Listen, such as "host:port" or "unix:/path/to/sock".
+// If addr is "", it is ":http", or ":https" if the certificate is set.
 //
 // If tlsFile is not nil, it must be certFile and keyFile. That's,
 //
 //     router := ship.New()
 //     rouetr.Start(addr, certFile, keyFile)
 //
+// which is equal to SetTLSCertFile(certFile, keyFile).
 func (s *Ship) Start(addr string, tlsFiles ...string) *Ship {
-	var cert, key string
 	if len(tlsFiles) == 2 && tlsFiles[0] != "" && tlsFiles[1] != "" {
-		cert = tlsFiles[0]
-		key = tlsFiles[1]
+		s.tlsCert = newTLSCertFile(tlsFiles[0], tlsFiles[1])
 	}
-	s.startServer(&http.Server{Addr: addr}, cert, key)
+	s.startServer(&http.Server{Addr: addr}, nil)
 	return s
 }
 
 // StartServer starts a HTTP server.
+//
+// If server.TLSConfig is nil, it will be built by the TLS options,
+// such as SetTLSConfig and SetTLSCertFile. If server.TLSConfig has
+// the certificates, it will serve HTTPS.
 func (s *Ship) StartServer(server *http.Server) {
-	s.startServer(server, "", "")
+	s.startServer(server, nil)
+}
+
+// Serve starts a HTTP server to serve the requests on all the listeners,
+// which share the lifecycle. That's, if any of them fails, the server
+// will be shut down. See the function Listen.
+//
+// For example,
+//
+//     public, _ := ship.Listen("0.0.0.0:80")
+//     admin, _ := ship.Listen("unix:/var/run/app/admin.sock")
+//     router := ship.New()
+//     router.Serve(public, admin)
+//
+func (s *Ship) Serve(listeners ...net.Listener) *Ship {
+	s.ServeServer(&http.Server{}, listeners...)
+	return s
+}
+
+// ServeServer is the same as Serve, but uses the given HTTP server.
+//
+// If server.TLSConfig has the certificates, it will serve HTTPS.
+func (s *Ship) ServeServer(server *http.Server, listeners ...net.Listener) {
+	if len(listeners) == 0 {
+		panic(errors.New("no listeners"))
+	}
+	s.startServer(server, listeners)
 }
 
 func (s *Ship) handleSignals(sigs ...os.Signal) {
@@ -589,7 +854,6 @@ func (s *Ship) handleSignals(sigs ...os.Signal) {
 func (s *Ship) runStop() {
 	s.lock.RLock()
 	defer s.lock.RUnlock()
-	defer close(s.done)
 	for _len := len(s.stopfs) - 1; _len >= 0; _len-- {
 		if r := s.stopfs[_len]; r != nil {
 			r.run()
@@ -602,17 +866,27 @@ func (s *Ship) stop() {
 }
 
 func (s *Ship) shutdown() {
-	s.once1.Do(func() { s.Shutdown(context.Background()) })
+	s.Shutdown(context.Background())
 }
 
-func (s *Ship) startServer(server *http.Server, certFile, keyFile string) {
-	defer s.shutdown()
-
+func (s *Ship) startServer(server *http.Server, listeners []net.Listener) {
 	if s.vhosts == nil {
 		s.logger.Error("forbid the virtual host to be started as a server")
+		closeListeners(listeners)
 		return
 	}
 
+	s.lock.Lock()
+	if s.server != nil {
+		s.lock.Unlock()
+		s.logger.Error("the server has been started")
+		closeListeners(listeners)
+		return
+	}
+	s.server = server
+	s.lock.Unlock()
+	defer s.shutdown()
+
 	server.ErrorLog = log.New(s.logger.Writer(), "",
 		log.LstdFlags|log.Lmicroseconds|log.Lshortfile)
 	if server.Handler == nil {
@@ -623,6 +897,9 @@ func (s *Ship) startServer(server *http.Server, certFile, keyFile string) {
 	if len(s.signals) > 0 {
 		go s.handleSignals(s.signals...)
 	}
+	if len(s.rsignals) > 0 {
+		go s.handleRestartSignals(s.rsignals...)
+	}
 
 	for _, r := range s.links {
 		s.RegisterOnShutdown(r.shutdown)
@@ -634,23 +911,10 @@ func (s *Ship) startServer(server *http.Server, certFile, keyFile string) {
 		server.ConnState = s.connState
 	}
 
-	var format string
-	if s.name == "" {
-		format = "The HTTP Server is shutdown"
-		s.logger.Info("The HTTP Server is running on %s", server.Addr)
-	} else {
+	format := "The HTTP Server is shutdown"
+	if s.name != "" {
 		format = fmt.Sprintf("The HTTP Server [%s] is shutdown", s.name)
-		s.logger.Info("The HTTP Server [%s] is running on %s",
-			s.name, server.Addr)
-	}
-
-	s.lock.Lock()
-	if s.server != nil {
-		s.logger.Error(format + ": the server has been started")
-		return
 	}
-	s.server = server
-	s.lock.Unlock()
 
 	var err error
 	s.RegisterOnShutdown(func() {
@@ -661,10 +925,97 @@ func (s *Ship) startServer(server *http.Server, certFile, keyFile string) {
 		}
 	})
 
-	if certFile != "" && keyFile != "" {
-		err = server.ListenAndServeTLS(certFile, keyFile)
+	// err is read by the shutdown function above, which is run
+	// with the read lock.
+	setError := func(e error) {
+		s.lock.Lock()
+		if err == nil {
+			err = e
+		}
+		s.lock.Unlock()
+	}
+
+	if server.TLSConfig == nil {
+		server.TLSConfig = s.newTLSConfig()
+	}
+	if len(s.tlsShips()) > 0 {
+		if e := s.loadCertificates(true); e != nil {
+			setError(e)
+			closeListeners(listeners)
+			return
+		}
+		if s.tlsReloadInterval > 0 || len(s.tlsReloadSignals) > 0 {
+			go s.reloadCertificatesLoop(s.tlsReloadInterval, s.tlsReloadSignals...)
+		}
+	}
+
+	for _, configure := range s.configures {
+		if e := configure(server); e != nil {
+			setError(e)
+			closeListeners(listeners)
+			return
+		}
+	}
+
+	isTLS := server.TLSConfig != nil &&
+		(len(server.TLSConfig.Certificates) > 0 || server.TLSConfig.GetCertificate != nil)
+
+	if len(listeners) == 0 {
+		addr := server.Addr
+		if addr == "" && isTLS {
+			addr = ":https"
+		}
+
+		ln, e := Listen(addr)
+		if e != nil {
+			setError(e)
+			return
+		}
+		listeners = []net.Listener{ln}
+	}
+
+	s.lock.Lock()
+	s.lns = listeners
+	s.lock.Unlock()
+
+	addrs := make([]string, len(listeners))
+	for i, ln := range listeners {
+		addrs[i] = ln.Addr().String()
+	}
+	if s.name == "" {
+		s.logger.Info("The HTTP Server is running on %s", strings.Join(addrs, ", "))
 	} else {
-		err = server.ListenAndServe()
+		s.logger.Info("The HTTP Server [%s] is running on %s",
+			s.name, strings.Join(addrs, ", "))
+	}
+
+	var wg sync.WaitGroup
+	atomic.StoreInt32(&s.ready, 1)
+	notifyRestartReady()
+	for _, ln := range listeners {
+		wg.Add(1)
+		go func(ln net.Listener) {
+			defer wg.Done()
+
+			var e error
+			if isTLS {
+				e = server.ServeTLS(ln, "", "")
+			} else {
+				e = server.Serve(ln)
+			}
+
+			if e != http.ErrServerClosed {
+				setError(e)
+				go s.shutdown() // Stop the other listeners.
+			}
+		}(ln)
+	}
+	wg.Wait()
+}
+
+func closeListeners(listeners []net.Listener) {
+	for _, ln := range listeners {
+		ln.Close()
 	}
 }
 
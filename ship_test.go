@@ -809,6 +809,69 @@ func TestShipVHost(t *testing.T) {
 	assert.Equal(t, "vhost2", rec.Body.String())
 }
 
+func TestShipVHostPattern(t *testing.T) {
+	s := New()
+	s.Route("/router").GET(func(c *Context) error { return c.String(200, "default") })
+
+	exact := s.VHost("api.example.com")
+	exact.Route("/router").GET(func(c *Context) error { return c.String(200, "exact") })
+
+	wildcard := s.VHost("*.static.example.com")
+	wildcard.Route("/router").GET(func(c *Context) error { return c.String(200, "wildcard") })
+
+	capture := s.VHost("{tenant}.example.com")
+	capture.Route("/router").GET(func(c *Context) error {
+		return c.String(200, "tenant="+c.HostParam("tenant"))
+	})
+
+	fallback := s.VHost("fallback")
+	fallback.Route("/router").GET(func(c *Context) error { return c.String(200, "fallback") })
+
+	for host, body := range map[string]string{
+		"api.example.com:8080":   "exact",
+		"API.Example.com":        "exact",
+		"a.b.static.example.com": "wildcard",
+		"abc.example.com:8080":   "tenant=abc",
+		"www.example.org":        "default",
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/router", nil)
+		req.Host = host
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, body, rec.Body.String(), host)
+	}
+
+	s.SetDefaultVHost("fallback")
+	req := httptest.NewRequest(http.MethodGet, "/router", nil)
+	req.Host = "www.example.org"
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, "fallback", rec.Body.String())
+}
+
+func TestShipSwapRouter(t *testing.T) {
+	s := New()
+	s.Route("/old").GET(OkHandler())
+
+	table := s.NewRouteTable()
+	table.Route("/new/:p1/:p2/:p3").GET(params2Handler)
+
+	code, _ := sendTestRequest(http.MethodGet, "/new/a/b/c", s)
+	assert.Equal(t, http.StatusNotFound, code)
+
+	old := s.SwapRouter(table.Router())
+	code, body := sendTestRequest(http.MethodGet, "/new/a/b/c", s)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "a|b", body)
+	code, _ = sendTestRequest(http.MethodGet, "/old", s)
+	assert.Equal(t, http.StatusNotFound, code)
+
+	s.SwapRouter(old)
+	code, _ = sendTestRequest(http.MethodGet, "/old", s)
+	assert.Equal(t, http.StatusOK, code)
+}
+
 func TestRouteStaticFile(t *testing.T) {
 	s := New()
 	s.Route("/README.md").StaticFile("./README.md")
@@ -1161,3 +1224,50 @@ func TestMiddleware(t *testing.T) {
 		t.Fail()
 	}
 }
+
+type wrappedError struct{ err error }
+
+func (e wrappedError) Error() string { return "wrapped: " + e.err.Error() }
+func (e wrappedError) Unwrap() error { return e.err }
+
+func TestHTTPErrorWrapping(t *testing.T) {
+	assert.Equal(t, "code=404, msg='not found'", ErrNotFound.NewMsg("not found").Error())
+
+	err := ErrBadRequest.NewError(ErrCookieNotFound)
+	assert.Equal(t, ErrCookieNotFound, err.Unwrap())
+	assert.True(t, ErrNotFound.NewMsg("not found").Is(ErrNotFound))
+	assert.False(t, ErrNotFound.Is(ErrNotFound.NewMsg("not found")))
+	assert.False(t, ErrNotFound.Is(ErrBadRequest))
+
+	he, ok := AsHTTPError(wrappedError{ErrForbidden.NewMsg("denied")})
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusForbidden, he.Code)
+	assert.Equal(t, "denied", he.Msg)
+
+	he, ok = toHTTPError(wrappedError{ErrRendererNotRegistered})
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusInternalServerError, he.Code)
+	assert.Equal(t, "renderer not registered", he.Msg)
+	_, ok = toHTTPError(ErrInvalidRedirectCode)
+	assert.False(t, ok)
+
+	for err, code := range map[error]int{
+		ErrTooManyRequests:                   http.StatusTooManyRequests,
+		wrappedError{ErrNotFound}:            http.StatusNotFound,
+		BindError{Err: ErrCookieNotFound}:    http.StatusBadRequest,
+		wrappedError{ErrSessionNotExist}:     http.StatusUnauthorized,
+		ErrMissingContentType:                http.StatusBadRequest,
+		ErrNoHandler:                         http.StatusNotFound,
+		ErrRendererNotRegistered:             http.StatusInternalServerError,
+		wrappedError{ErrInvalidRedirectCode}: http.StatusInternalServerError,
+	} {
+		assert.Equal(t, code, ErrorStatusCode(err), err.Error())
+	}
+
+	s := New(DisableErrorLog(true))
+	s.Route("/").GET(func(ctx *Context) error { return wrappedError{ErrSessionNotExist} })
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "wrapped: session does not exist", rec.Body.String())
+}
@@ -0,0 +1,155 @@
+// Copyright 2019 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ship
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// ShutdownPhase is the phase to run the shutdown hooks.
+type ShutdownPhase int
+
+// Predefine some shutdown phases, which run in turn when shutting down.
+const (
+	// ShutdownBeforeDrain is the phase after marking the ship not ready
+	// and before draining the connections.
+	ShutdownBeforeDrain ShutdownPhase = iota
+
+	// ShutdownAfterDrain is the phase after the connections are drained
+	// or closed forcibly.
+	ShutdownAfterDrain
+
+	// ShutdownFinal is the last phase, which runs after the functions
+	// registered by RegisterOnShutdown have finished.
+	ShutdownFinal
+
+	shutdownPhaseNum
+)
+
+func (p ShutdownPhase) String() string {
+	switch p {
+	case ShutdownBeforeDrain:
+		return "before-drain"
+	case ShutdownAfterDrain:
+		return "after-drain"
+	case ShutdownFinal:
+		return "final"
+	default:
+		return fmt.Sprintf("ShutdownPhase(%d)", int(p))
+	}
+}
+
+// RegisterShutdownHook registers some hooks to run in the shutdown phase.
+//
+// The hooks in the same phase run in turn by the order of the registration,
+// and share a context, the deadline of which is set by SetShutdownHookTimeout.
+// The errors returned by the hooks will be logged.
+//
+// The shutdown process is as follows:
+//
+//     1. Mark the ship not ready. See ReadinessHandler.
+//     2. Run the hooks of ShutdownBeforeDrain.
+//     3. Wait for the delay set by SetShutdownDelay.
+//     4. Drain the connections, and run the functions registered
+//        by RegisterOnShutdown at the same time. If the timeout set by
+//        SetShutdownTimeout is exceeded, close the connections forcibly.
+//     5. Run the hooks of ShutdownAfterDrain.
+//     6. Wait for the functions registered by RegisterOnShutdown.
+//     7. Run the hooks of ShutdownFinal.
+//
+func (s *Ship) RegisterShutdownHook(phase ShutdownPhase, hooks ...func(context.Context) error) *Ship {
+	if phase < 0 || phase >= shutdownPhaseNum {
+		panic(fmt.Errorf("unknown shutdown phase '%s'", phase))
+	}
+
+	s.lock.Lock()
+	s.hooks[phase] = append(s.hooks[phase], hooks...)
+	s.lock.Unlock()
+	return s
+}
+
+// IsReady reports whether the server is running and ready to serve
+// the requests, which is false after starting to shut down.
+func (s *Ship) IsReady() bool {
+	return atomic.LoadInt32(&s.ready) == 1
+}
+
+// ReadinessHandler returns a handler to report whether the ship is ready,
+// which returns 200 if ready, or 503. So the load balancers can stop sending
+// the traffic when the ship starts to shut down. For example,
+//
+//     router := ship.New(ship.SetShutdownDelay(time.Second * 5))
+//     router.R("/readyz").GET(router.ReadinessHandler())
+//
+func (s *Ship) ReadinessHandler() Handler {
+	return func(ctx *Context) error {
+		if s.IsReady() {
+			return ctx.NoContent(http.StatusOK)
+		}
+		return ctx.NoContent(http.StatusServiceUnavailable)
+	}
+}
+
+func (s *Ship) runShutdownHooks(phase ShutdownPhase) {
+	s.lock.RLock()
+	hooks := s.hooks[phase]
+	s.lock.RUnlock()
+	if len(hooks) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.shutdownHookTimeout)
+	defer cancel()
+	for _, hook := range hooks {
+		if err := hook(ctx); err != nil {
+			s.logger.Error("failed to run the %s shutdown hook: %s", phase, err)
+		}
+	}
+}
+
+func (s *Ship) shutdownServer(ctx context.Context, server *http.Server) (err error) {
+	atomic.StoreInt32(&s.ready, 0)
+	s.runShutdownHooks(ShutdownBeforeDrain)
+
+	if s.shutdownDelay > 0 {
+		timer := time.NewTimer(s.shutdownDelay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+		}
+	}
+
+	if s.shutdownTimeout > 0 {
+		var cancel func()
+		ctx, cancel = context.WithTimeout(ctx, s.shutdownTimeout)
+		defer cancel()
+	}
+
+	if err = server.Shutdown(ctx); err != nil {
+		s.logger.Error("failed to drain the connections, and close them forcibly: %s", err)
+		server.Close()
+	}
+
+	s.runShutdownHooks(ShutdownAfterDrain)
+	s.stop()
+	s.runShutdownHooks(ShutdownFinal)
+	close(s.done)
+	return
+}
@@ -0,0 +1,108 @@
+// Copyright 2019 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ship
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGracefulShutdown(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	addr := ln.Addr().String()
+
+	var lock sync.Mutex
+	var phases []string
+	record := func(phase string) {
+		lock.Lock()
+		phases = append(phases, phase)
+		lock.Unlock()
+	}
+
+	s := New(SetSignal([]os.Signal{}), SetShutdownTimeout(time.Millisecond*100),
+		SetLogger(NewNoLevelLogger(ioutil.Discard)))
+	s.RegisterOnShutdown(func() { record("stop") })
+	for _, phase := range []ShutdownPhase{ShutdownFinal, ShutdownAfterDrain, ShutdownBeforeDrain} {
+		phase := phase
+		s.RegisterShutdownHook(phase, func(ctx context.Context) error {
+			if _, ok := ctx.Deadline(); !ok {
+				t.Errorf("the context of the %s hook has no deadline", phase)
+			}
+			record(phase.String())
+			return errors.New("error")
+		})
+	}
+
+	started := make(chan struct{})
+	s.R("/readyz").GET(s.ReadinessHandler())
+	s.R("/slow").GET(func(ctx *Context) error {
+		close(started)
+		time.Sleep(time.Second * 3)
+		return nil
+	})
+
+	go s.Serve(ln)
+	for i := 0; i < 100 && !s.IsReady(); i++ {
+		time.Sleep(time.Millisecond * 10)
+	}
+	assert.True(t, s.IsReady())
+
+	var resp *http.Response
+	for i := 0; i < 100; i++ {
+		if resp, err = http.Get("http://" + addr + "/readyz"); err == nil {
+			resp.Body.Close()
+			break
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+	if assert.NoError(t, err) {
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+
+	go http.Get("http://" + addr + "/slow")
+	<-started
+
+	start := time.Now()
+	assert.Error(t, s.Shutdown(context.Background()))
+	assert.True(t, time.Since(start) < time.Second)
+	assert.NoError(t, s.Shutdown(context.Background()))
+	s.Wait()
+
+	assert.False(t, s.IsReady())
+	assert.Equal(t, []string{"before-drain", "stop", "after-drain", "final"}, phases)
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	assert.Equal(t, time.Second*30, New().shutdownTimeout)
+	assert.Equal(t, time.Duration(0), New(SetShutdownTimeout(0)).shutdownTimeout)
+	assert.Equal(t, time.Second*30, New().Clone().shutdownTimeout)
+}
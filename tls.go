@@ -0,0 +1,194 @@
+// Copyright 2019 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ship
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tlsCertFile is the certificate loaded from the files, which will be
+// reloaded when the files have changed.
+type tlsCertFile struct {
+	certFile string
+	keyFile  string
+
+	lock    sync.RWMutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+func newTLSCertFile(certFile, keyFile string) *tlsCertFile {
+	return &tlsCertFile{certFile: certFile, keyFile: keyFile}
+}
+
+func (c *tlsCertFile) Certificate() *tls.Certificate {
+	c.lock.RLock()
+	cert := c.cert
+	c.lock.RUnlock()
+	return cert
+}
+
+// Load loads the certificate from the files if they have changed
+// or force is true, and reports whether it is reloaded.
+func (c *tlsCertFile) Load(force bool) (reloaded bool, err error) {
+	var modTime time.Time
+	for _, file := range []string{c.certFile, c.keyFile} {
+		fi, err := os.Stat(file)
+		if err != nil {
+			return false, err
+		} else if t := fi.ModTime(); t.After(modTime) {
+			modTime = t
+		}
+	}
+
+	c.lock.RLock()
+	unchanged := c.cert != nil && modTime.Equal(c.modTime)
+	c.lock.RUnlock()
+	if unchanged && !force {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return false, err
+	}
+
+	c.lock.Lock()
+	c.cert = &cert
+	c.modTime = modTime
+	c.lock.Unlock()
+	return true, nil
+}
+
+// tlsShips returns the ship and all its virtual hosts which have
+// the certificate.
+func (s *Ship) tlsShips() []*Ship {
+	ships := make([]*Ship, 0, len(s.vhosts)+len(s.vhostPatterns)+1)
+	if s.tlsCert != nil {
+		ships = append(ships, s)
+	}
+	for _, vhost := range s.vhosts {
+		if vhost.tlsCert != nil {
+			ships = append(ships, vhost)
+		}
+	}
+	for _, p := range s.vhostPatterns {
+		if p.vhost.tlsCert != nil {
+			ships = append(ships, p.vhost)
+		}
+	}
+	return ships
+}
+
+func (s *Ship) loadCertificates(force bool) (err error) {
+	for _, ship := range s.tlsShips() {
+		reloaded, e := ship.tlsCert.Load(force)
+		if e != nil {
+			e = fmt.Errorf("failed to load the certificate '%s': %s", ship.tlsCert.certFile, e)
+			s.logger.Error("%s", e)
+			if err == nil {
+				err = e
+			}
+		} else if reloaded {
+			s.logger.Info("load the certificate '%s'", ship.tlsCert.certFile)
+		}
+	}
+	return
+}
+
+// ReloadCertificates reloads the certificates of the ship and its virtual
+// hosts set by SetTLSCertFile, which will be used by the new connections.
+//
+// If a certificate fails to be reloaded, the old one will go on being used.
+func (s *Ship) ReloadCertificates() error {
+	return s.loadCertificates(true)
+}
+
+// getCertificate selects the certificate by the SNI server name
+// from the virtual hosts, or uses the certificate of the ship.
+func (s *Ship) getCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	if name := strings.TrimSuffix(hello.ServerName, "."); name != "" && s.vhosts != nil {
+		if vhost, _, _ := s.findVHost(name); vhost != nil && vhost.tlsCert != nil {
+			if cert := vhost.tlsCert.Certificate(); cert != nil {
+				return cert, nil
+			}
+		}
+	}
+
+	if s.tlsCert != nil {
+		if cert := s.tlsCert.Certificate(); cert != nil {
+			return cert, nil
+		}
+	}
+
+	if s.tlsConfig != nil && len(s.tlsConfig.Certificates) > 0 {
+		return nil, nil // Use the default certificates of tls.Config.
+	}
+	return nil, fmt.Errorf("no certificate for the server name '%s'", hello.ServerName)
+}
+
+// newTLSConfig returns a new tls.Config to serve HTTPS, or nil if the ship
+// has no certificates.
+func (s *Ship) newTLSConfig() *tls.Config {
+	hasCert := len(s.tlsShips()) > 0
+	if !hasCert && s.tlsConfig == nil {
+		return nil
+	}
+
+	config := new(tls.Config)
+	if s.tlsConfig != nil {
+		config = s.tlsConfig.Clone()
+	}
+	if config.MinVersion == 0 {
+		config.MinVersion = tls.VersionTLS12
+	}
+	if hasCert {
+		config.GetCertificate = s.getCertificate
+	}
+	return config
+}
+
+func (s *Ship) reloadCertificatesLoop(interval time.Duration, sigs ...os.Signal) {
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	var ss chan os.Signal
+	if len(sigs) > 0 {
+		ss = make(chan os.Signal, 1)
+		signal.Notify(ss, sigs...)
+		defer signal.Stop(ss)
+	}
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-tick:
+			s.loadCertificates(false)
+		case <-ss:
+			s.ReloadCertificates()
+		}
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2019 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ship
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTestCertificate(t *testing.T, certFile, keyFile, name string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPem := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	if err = ioutil.WriteFile(certFile, certPem, 0600); err != nil {
+		t.Fatal(err)
+	} else if err = ioutil.WriteFile(keyFile, keyPem, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTLSCertificates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ship")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	defaultCert := filepath.Join(dir, "default.crt")
+	defaultKey := filepath.Join(dir, "default.key")
+	exampleCert := filepath.Join(dir, "example.crt")
+	exampleKey := filepath.Join(dir, "example.key")
+	writeTestCertificate(t, defaultCert, defaultKey, "default")
+	writeTestCertificate(t, exampleCert, exampleKey, "www.example.com")
+
+	s := New(SetSignal([]os.Signal{}), SetLogger(NewNoLevelLogger(ioutil.Discard)),
+		SetTLSCertFile(defaultCert, defaultKey), SetTLSReload(time.Millisecond*10))
+	s.VHost("*.example.com").Configure(SetTLSCertFile(exampleCert, exampleKey))
+
+	ln, err := Listen("127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	go s.Serve(ln)
+	defer s.Shutdown(context.Background())
+	for i := 0; i < 100 && !s.IsReady(); i++ {
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	getCertName := func(serverName string, maxVersion uint16) string {
+		conn, err := tls.Dial("tcp", ln.Addr().String(), &tls.Config{
+			ServerName:         serverName,
+			MaxVersion:         maxVersion,
+			InsecureSkipVerify: true,
+		})
+		if err != nil {
+			return err.Error()
+		}
+		defer conn.Close()
+		return conn.ConnectionState().PeerCertificates[0].Subject.CommonName
+	}
+
+	assert.Equal(t, "www.example.com", getCertName("www.example.com", 0))
+	assert.Equal(t, "default", getCertName("www.other.com", 0))
+	assert.NotEqual(t, "default", getCertName("www.other.com", tls.VersionTLS11))
+
+	// Reload the certificate when the files have changed.
+	writeTestCertificate(t, defaultCert, defaultKey, "default2")
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(defaultCert, future, future)
+
+	var name string
+	for i := 0; i < 100; i++ {
+		if name = getCertName("", 0); name == "default2" {
+			break
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+	assert.Equal(t, "default2", name)
+}
+
+func TestCloneTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ship")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	certFile := filepath.Join(dir, "default.crt")
+	keyFile := filepath.Join(dir, "default.key")
+	writeTestCertificate(t, certFile, keyFile, "default")
+
+	var states int32
+	s := New(SetSignal([]os.Signal{}), SetLogger(NewNoLevelLogger(ioutil.Discard)),
+		SetTLSConfig(&tls.Config{MaxVersion: tls.VersionTLS12}),
+		SetTLSCertFile(certFile, keyFile), SetTLSReload(time.Minute))
+	s.SetConnStateHandler(func(net.Conn, http.ConnState) { atomic.AddInt32(&states, 1) })
+
+	clone := s.Clone()
+	assert.Equal(t, time.Minute, clone.tlsReloadInterval)
+
+	ln, err := Listen("127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	go clone.Serve(ln)
+	defer clone.Shutdown(context.Background())
+	for i := 0; i < 100 && !clone.IsReady(); i++ {
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	conn, err := tls.Dial("tcp", ln.Addr().String(), &tls.Config{InsecureSkipVerify: true})
+	if assert.NoError(t, err) {
+		state := conn.ConnectionState()
+		conn.Close()
+		assert.Equal(t, uint16(tls.VersionTLS12), state.Version)
+		assert.Equal(t, "default", state.PeerCertificates[0].Subject.CommonName)
+		assert.NotEqual(t, int32(0), atomic.LoadInt32(&states))
+	}
+}
@@ -0,0 +1,386 @@
+// Copyright 2019 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ship
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Validator is the interface to validate whether the value is valid.
+type Validator interface {
+	// Validate validates whether v is valid.
+	//
+	// If invalid, it should return ValidationErrors or an HTTPError.
+	Validate(v interface{}) error
+}
+
+type validatorFunc func(interface{}) error
+
+func (f validatorFunc) Validate(v interface{}) error {
+	return f(v)
+}
+
+// ValidatorFunc converts a function to Validator.
+func ValidatorFunc(f func(v interface{}) error) Validator {
+	return validatorFunc(f)
+}
+
+// FieldError represents a rule that a struct field fails to pass.
+type FieldError struct {
+	Field string `json:"field" xml:"field"` // The path of the field, such as "Address.City".
+	Rule  string `json:"rule" xml:"rule"`   // The failed rule, such as "min=1".
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("the field '%s' fails to pass the rule '%s'", e.Field, e.Rule)
+}
+
+// ValidationErrors is a set of the rules that the struct fields fail to pass.
+type ValidationErrors []FieldError
+
+func (es ValidationErrors) Error() string {
+	ss := make([]string, len(es))
+	for i, e := range es {
+		ss[i] = e.Error()
+	}
+	return strings.Join(ss, "; ")
+}
+
+// ValidationRule is the rule to validate the field with the rule parameter,
+// which reports whether the field value is valid.
+type ValidationRule func(field reflect.Value, param string) (ok bool)
+
+// TagValidator is a validator based on the struct tag, for example,
+//
+//     type User struct {
+//         Name  string   `validate:"required,min=1,max=64"`
+//         Email string   `validate:"omitempty,email"`
+//         Role  string   `validate:"oneof=admin user"`
+//         Tags  []string `validate:"max=8"`
+//     }
+//
+// The builtin rules are
+//
+//     required   The field must not be the zero value.
+//     omitempty  Skip the rest rules if the field is the zero value.
+//     min=N      The number must not be less than N, or the length of
+//                the string, slice, array or map must not be less than N.
+//     max=N      Like min, but not be greater than N.
+//     len=N      Like min, but be equal to N.
+//     email      The string must be an email address.
+//     oneof=A B  The string or the number must be one of the values
+//                separated by the whitespace.
+//
+// It will validate the nested struct, the pointer to struct and the slice
+// of struct recursively.
+type TagValidator struct {
+	tag   string
+	lock  sync.RWMutex
+	rules map[string]ValidationRule
+	cache sync.Map // map[reflect.Type][]fieldRules
+}
+
+type fieldRule struct {
+	name  string
+	param string
+	rule  ValidationRule
+}
+
+type fieldRules struct {
+	index int
+	name  string
+	rules []fieldRule
+}
+
+// NewTagValidator returns a new TagValidator, the tag of which is
+// "validate" by default.
+func NewTagValidator(tag ...string) *TagValidator {
+	v := &TagValidator{tag: "validate", rules: make(map[string]ValidationRule, 8)}
+	if len(tag) > 0 && tag[0] != "" {
+		v.tag = tag[0]
+	}
+
+	v.AddRule("required", validateRequired)
+	v.AddRule("min", validateMin)
+	v.AddRule("max", validateMax)
+	v.AddRule("len", validateLen)
+	v.AddRule("email", validateEmail)
+	v.AddRule("oneof", validateOneOf)
+	return v
+}
+
+// AddRule adds a validation rule named name, which will override
+// the old rule with the same name.
+//
+// Notice: "omitempty" is the reserved rule name.
+func (v *TagValidator) AddRule(name string, rule ValidationRule) {
+	if name == "" || name == "omitempty" {
+		panic(fmt.Errorf("invalid validation rule name '%s'", name))
+	} else if rule == nil {
+		panic(errors.New("the validation rule is nil"))
+	}
+
+	v.lock.Lock()
+	v.rules[name] = rule
+	v.lock.Unlock()
+}
+
+// Validate implements the interface Validator.
+//
+// If v is not a struct or a pointer to struct, it does nothing.
+func (v *TagValidator) Validate(value interface{}) error {
+	var errs ValidationErrors
+	if err := v.validate(reflect.ValueOf(value), "", &errs); err != nil {
+		return err
+	} else if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (v *TagValidator) validate(value reflect.Value, prefix string,
+	errs *ValidationErrors) error {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+
+	switch value.Kind() {
+	case reflect.Struct:
+	case reflect.Slice, reflect.Array:
+		for i, _len := 0, value.Len(); i < _len; i++ {
+			name := fmt.Sprintf("%s[%d]", prefix, i)
+			if err := v.validate(value.Index(i), name, errs); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return nil
+	}
+
+	frs, err := v.getFieldRules(value.Type())
+	if err != nil {
+		return err
+	}
+
+	for _, fr := range frs {
+		field := value.Field(fr.index)
+		name := fr.name
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+
+		if len(fr.rules) == 0 || fr.rules[0].name != "omitempty" || !isZero(field) {
+			for _, r := range fr.rules {
+				if r.rule != nil && !r.rule(field, r.param) {
+					rule := r.name
+					if r.param != "" {
+						rule = rule + "=" + r.param
+					}
+					*errs = append(*errs, FieldError{Field: name, Rule: rule})
+				}
+			}
+		}
+
+		if err = v.validate(field, name, errs); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (v *TagValidator) getFieldRules(t reflect.Type) ([]fieldRules, error) {
+	if frs, ok := v.cache.Load(t); ok {
+		return frs.([]fieldRules), nil
+	}
+
+	v.lock.RLock()
+	defer v.lock.RUnlock()
+
+	frs := make([]fieldRules, 0, t.NumField())
+	for i, num := 0, t.NumField(); i < num; i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" { // Unexported
+			continue
+		}
+
+		tag := field.Tag.Get(v.tag)
+		if tag == "-" {
+			continue
+		}
+
+		fr := fieldRules{index: i, name: field.Name}
+		for _, r := range strings.Split(tag, ",") {
+			if r = strings.TrimSpace(r); r == "" {
+				continue
+			}
+
+			var param string
+			if index := strings.IndexByte(r, '='); index > -1 {
+				r, param = r[:index], r[index+1:]
+			}
+
+			if r == "omitempty" {
+				fr.rules = append([]fieldRule{{name: r}}, fr.rules...)
+				continue
+			}
+
+			rule := v.rules[r]
+			if rule == nil {
+				return nil, fmt.Errorf("unknown validation rule '%s' of the field '%s'",
+					r, field.Name)
+			}
+			fr.rules = append(fr.rules, fieldRule{name: r, param: param, rule: rule})
+		}
+		frs = append(frs, fr)
+	}
+
+	v.cache.Store(t, frs)
+	return frs, nil
+}
+
+func isZero(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return v.Len() == 0
+	case reflect.Ptr, reflect.Interface, reflect.Chan, reflect.Func:
+		return v.IsNil()
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Struct:
+		return reflect.DeepEqual(v.Interface(), reflect.Zero(v.Type()).Interface())
+	}
+	return false
+}
+
+func indirect(v reflect.Value) (reflect.Value, bool) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return v, false
+		}
+		v = v.Elem()
+	}
+	return v, true
+}
+
+// compareSize compares the number or the length of v with param,
+// and returns -1, 0 or 1.
+func compareSize(v reflect.Value, param string) (int, bool) {
+	v, ok := indirect(v)
+	if !ok {
+		return 0, false
+	}
+
+	var f float64
+	switch v.Kind() {
+	case reflect.String:
+		f = float64(utf8.RuneCountInString(v.String()))
+	case reflect.Slice, reflect.Map, reflect.Array:
+		f = float64(v.Len())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		f = float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		f = float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		f = v.Float()
+	default:
+		return 0, false
+	}
+
+	p, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	switch {
+	case f < p:
+		return -1, true
+	case f > p:
+		return 1, true
+	default:
+		return 0, true
+	}
+}
+
+func validateRequired(v reflect.Value, param string) bool {
+	return !isZero(v)
+}
+
+func validateMin(v reflect.Value, param string) bool {
+	r, ok := compareSize(v, param)
+	return ok && r >= 0
+}
+
+func validateMax(v reflect.Value, param string) bool {
+	r, ok := compareSize(v, param)
+	return ok && r <= 0
+}
+
+func validateLen(v reflect.Value, param string) bool {
+	r, ok := compareSize(v, param)
+	return ok && r == 0
+}
+
+var emailRegexp = regexp.MustCompile(`^[a-zA-Z0-9.!#$%&'*+/=?^_{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$`)
+
+func validateEmail(v reflect.Value, param string) bool {
+	if v, ok := indirect(v); ok && v.Kind() == reflect.String {
+		return emailRegexp.MatchString(v.String())
+	}
+	return false
+}
+
+func validateOneOf(v reflect.Value, param string) bool {
+	v, ok := indirect(v)
+	if !ok {
+		return false
+	}
+
+	var s string
+	switch v.Kind() {
+	case reflect.String:
+		s = v.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		s = strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		s = strconv.FormatUint(v.Uint(), 10)
+	default:
+		return false
+	}
+
+	for _, one := range strings.Fields(param) {
+		if one == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+// Copyright 2019 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ship
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTagValidator(t *testing.T) {
+	type Item struct {
+		SKU string `validate:"required,len=4"`
+	}
+
+	type User struct {
+		Name  string  `validate:"required,min=1,max=8"`
+		Email string  `validate:"omitempty,email"`
+		Role  string  `validate:"oneof=admin user"`
+		Age   int     `validate:"min=1,max=150"`
+		Items []Item  `validate:"max=2"`
+		Next  *Item   `validate:"required"`
+		Score float64 `validate:"-"`
+	}
+
+	v := NewTagValidator()
+	assert.Nil(t, v.Validate(&User{Name: "Aaron", Role: "user", Age: 18,
+		Items: []Item{{SKU: "abcd"}}, Next: &Item{SKU: "abcd"}}))
+
+	err := v.Validate(User{Name: "Aaron Smith", Email: "abc", Role: "guest",
+		Items: []Item{{SKU: "abc"}}})
+	assert.Equal(t, ValidationErrors{
+		{Field: "Name", Rule: "max=8"},
+		{Field: "Email", Rule: "email"},
+		{Field: "Role", Rule: "oneof=admin user"},
+		{Field: "Age", Rule: "min=1"},
+		{Field: "Items[0].SKU", Rule: "len=4"},
+		{Field: "Next", Rule: "required"},
+	}, err)
+}
+
+func TestContextBindValidate(t *testing.T) {
+	type User struct {
+		Name string `json:"name" query:"name" validate:"required"`
+		Age  int    `json:"age" query:"age" validate:"min=18"`
+	}
+
+	s := New(SetValidator(NewTagValidator()))
+	s.Route("/json").POST(func(ctx *Context) error {
+		var u User
+		if err := ctx.Bind(&u); err != nil {
+			return err
+		}
+		return ctx.String(200, u.Name)
+	})
+	s.Route("/query").GET(func(ctx *Context) error {
+		var u User
+		if err := ctx.BindQuery(&u); err != nil {
+			return err
+		}
+		return ctx.String(200, u.Name)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/json", strings.NewReader(`{"name":"Aaron","age":20}`))
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "Aaron", rec.Body.String())
+
+	req = httptest.NewRequest(http.MethodPost, "/json", strings.NewReader(`{"age":10}`))
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "the field 'Name' fails to pass the rule 'required'; "+
+		"the field 'Age' fails to pass the rule 'min=18'", rec.Body.String())
+
+	req = httptest.NewRequest(http.MethodGet, "/query?age=20", nil)
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -0,0 +1,129 @@
+// Copyright 2019 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ship
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+)
+
+// vhostPattern is a virtual host pattern with the wildcard or the captures,
+// such as "*.example.com" or "{tenant}.example.com".
+type vhostPattern struct {
+	host   string
+	port   bool // Whether the pattern contains the port.
+	regexp *regexp.Regexp
+	names  []string
+	vhost  *Ship
+}
+
+// isVHostPattern reports whether the host is a pattern, not an exact host.
+func isVHostPattern(host string) bool {
+	return strings.HasPrefix(host, "*.") || strings.IndexByte(host, '{') > -1
+}
+
+func newVHostPattern(host string, vhost *Ship) *vhostPattern {
+	var port bool
+	hostname := host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		hostname = h
+		port = true
+	}
+
+	var names []string
+	buf := make([]byte, 0, len(host)*2)
+	buf = append(buf, '^')
+	for i, label := range strings.Split(hostname, ".") {
+		switch {
+		case label == "*":
+			if i > 0 {
+				panic(fmt.Errorf("the wildcard must be the leading label of the host '%s'", host))
+			}
+			buf = append(buf, ".+"...)
+		case len(label) > 2 && label[0] == '{' && label[len(label)-1] == '}':
+			name := label[1 : len(label)-1]
+			for _, n := range names {
+				if n == name {
+					panic(fmt.Errorf("the capture '%s' is duplicate in the host '%s'", name, host))
+				}
+			}
+			names = append(names, name)
+			buf = append(buf, "([^.]+)"...)
+		case strings.ContainsAny(label, "*{}"):
+			panic(fmt.Errorf("invalid label '%s' in the host '%s'", label, host))
+		default:
+			buf = append(buf, regexp.QuoteMeta(label)...)
+		}
+		buf = append(buf, `\.`...)
+	}
+	buf = buf[:len(buf)-2]
+	if port {
+		buf = append(buf, regexp.QuoteMeta(host[len(hostname):])...)
+	}
+	buf = append(buf, '$')
+
+	return &vhostPattern{
+		host:   host,
+		port:   port,
+		names:  names,
+		vhost:  vhost,
+		regexp: regexp.MustCompile(string(buf)),
+	}
+}
+
+// Match reports whether the host matches the pattern, and returns
+// the values of the captures if matching.
+func (p *vhostPattern) Match(host, hostname string) (values []string, ok bool) {
+	if !p.port {
+		host = hostname
+	}
+
+	if ms := p.regexp.FindStringSubmatch(host); ms != nil {
+		return ms[1:], true
+	}
+	return nil, false
+}
+
+// splitHostPort returns the host without the port.
+func splitHostPort(host string) string {
+	if i := strings.LastIndexByte(host, ':'); i > -1 && strings.IndexByte(host[i:], ']') == -1 {
+		return host[:i]
+	}
+	return host
+}
+
+// findVHost returns the virtual host matching the request host,
+// and the names and the values of the captures of the host.
+func (s *Ship) findVHost(host string) (vhost *Ship, names, values []string) {
+	host = strings.ToLower(host)
+	if vhost = s.vhosts[host]; vhost != nil {
+		return
+	}
+
+	hostname := splitHostPort(host)
+	if vhost = s.vhosts[hostname]; vhost != nil {
+		return
+	}
+
+	for _, p := range s.vhostPatterns {
+		if values, ok := p.Match(host, hostname); ok {
+			return p.vhost, p.names, values
+		}
+	}
+
+	return s.defaultVHost, nil, nil
+}